@@ -0,0 +1,532 @@
+package lgraph
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// lts is the labeled transition system of the parts of one or more graphs
+// reachable from their roots. Its states are numbered densely, and its actions
+// are epsilon plus the intervals of runes on which all edges agree, so that
+// set-labeled edges can be compared rune by rune.
+type lts struct {
+	graph  []int  // state -> index of the graph it comes from
+	node   []node // state -> node in that graph
+	index  []map[node]int
+	action []runeRange // action -> interval of runes; action 0 is epsilon
+	succ   [][]ltsEdge // state -> outgoing transitions
+}
+
+type ltsEdge struct {
+	action, target int
+}
+
+// epsilonAction is the action of the silent edges.
+const epsilonAction = 0
+
+// newLTS explores graphs[i] from roots[i] for every i and builds their
+// combined transition system.
+func newLTS(graphs []LGraph, roots []node) *lts {
+	m := &lts{index: make([]map[node]int, len(graphs))}
+	var edges [][]edge
+	for i, g := range graphs {
+		m.index[i] = map[node]int{}
+		queue := []node{roots[i]}
+		m.index[i][roots[i]] = len(m.node)
+		m.graph = append(m.graph, i)
+		m.node = append(m.node, roots[i])
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			out, _ := g(current)
+			edges = append(edges, out)
+			for _, edge := range out {
+				if _, seen := m.index[i][edge.destination]; !seen {
+					m.index[i][edge.destination] = len(m.node)
+					m.graph = append(m.graph, i)
+					m.node = append(m.node, edge.destination)
+					queue = append(queue, edge.destination)
+				}
+			}
+		}
+	}
+
+	// cut the runes used by any edge into intervals
+	cutSet := map[rune]bool{}
+	for _, out := range edges {
+		for _, edge := range out {
+			for _, r := range edge.accepts() {
+				cutSet[r.lo] = true
+				cutSet[r.hi+1] = true
+			}
+		}
+	}
+	cuts := make([]rune, 0, len(cutSet))
+	for r := range cutSet {
+		cuts = append(cuts, r)
+	}
+	sort.Slice(cuts, func(i, j int) bool { return cuts[i] < cuts[j] })
+	m.action = []runeRange{{}}
+	for i := 0; i+1 < len(cuts); i++ {
+		m.action = append(m.action, runeRange{cuts[i], cuts[i+1] - 1})
+	}
+
+	m.succ = make([][]ltsEdge, len(m.node))
+	for state, out := range edges {
+		seen := map[ltsEdge]bool{}
+		add := func(e ltsEdge) {
+			if !seen[e] {
+				seen[e] = true
+				m.succ[state] = append(m.succ[state], e)
+			}
+		}
+		for _, edge := range out {
+			target := m.index[m.graph[state]][edge.destination]
+			if edge.epsilon {
+				add(ltsEdge{epsilonAction, target})
+				continue
+			}
+			for _, r := range edge.accepts() {
+				first := sort.Search(len(cuts), func(i int) bool { return cuts[i] >= r.lo })
+				last := sort.Search(len(cuts), func(i int) bool { return cuts[i] > r.hi })
+				for a := first; a < last; a++ {
+					add(ltsEdge{a + 1, target})
+				}
+			}
+		}
+	}
+	return m
+}
+
+// label formats action a for use in formulas.
+func (m *lts) label(a int) string {
+	if a == epsilonAction {
+		return "ε"
+	}
+	r := m.action[a]
+	if r.lo == r.hi {
+		return string(r.lo)
+	}
+	return runeSet{r}.String()
+}
+
+// partition is a partition of the states of an lts into blocks.
+type partition struct {
+	block   []int   // state -> block
+	members [][]int // block -> states
+	pos     []int   // state -> position in members of its block
+}
+
+func newPartition(n int) *partition {
+	p := &partition{block: make([]int, n), members: [][]int{make([]int, n)}, pos: make([]int, n)}
+	for x := 0; x < n; x++ {
+		p.members[0][x] = x
+		p.pos[x] = x
+	}
+	return p
+}
+
+// split moves the distinct states of marked out of every block that has both
+// marked and unmarked states into a new block. It returns the pairs of
+// (old block, new block) that were created.
+func (p *partition) split(marked []int) [][2]int {
+	count := map[int]int{}
+	for _, x := range marked {
+		count[p.block[x]]++
+	}
+	created := map[int]int{}
+	var splits [][2]int
+	for _, x := range marked {
+		b := p.block[x]
+		nb, ok := created[b]
+		if !ok {
+			if count[b] == len(p.members[b]) {
+				continue
+			}
+			nb = len(p.members)
+			p.members = append(p.members, nil)
+			created[b] = nb
+			splits = append(splits, [2]int{b, nb})
+		}
+		// swap x with the last member of b and move it over to nb
+		last := p.members[b][len(p.members[b])-1]
+		p.members[b][p.pos[x]] = last
+		p.pos[last] = p.pos[x]
+		p.members[b] = p.members[b][:len(p.members[b])-1]
+		p.block[x] = nb
+		p.pos[x] = len(p.members[nb])
+		p.members[nb] = append(p.members[nb], x)
+	}
+	return splits
+}
+
+// bisimulation computes the coarsest partition of the states of m into
+// strongly bisimilar classes, using the Paige-Tarjan algorithm: the partition
+// is kept stable with respect to a coarser partition of compound blocks, and
+// each compound block is refined by its smaller half at a time.
+func (m *lts) bisimulation() *partition {
+	n := len(m.node)
+	pred := make([][][]int, len(m.action))
+	for a := range pred {
+		pred[a] = make([][]int, n)
+	}
+	for x, out := range m.succ {
+		for _, e := range out {
+			pred[e.action][e.target] = append(pred[e.action][e.target], x)
+		}
+	}
+
+	type countKey struct {
+		state, action, compound int
+	}
+	count := map[countKey]int{}
+	q := newPartition(n)
+	compound := []int{0}  // block -> compound block
+	parts := [][]int{{0}} // compound block -> blocks
+	pending := []int{}    // compound blocks with more than one block
+	isPending := map[int]bool{}
+	register := func(splits [][2]int) {
+		for _, s := range splits {
+			c := compound[s[0]]
+			compound = append(compound, c)
+			parts[c] = append(parts[c], s[1])
+			if !isPending[c] {
+				isPending[c] = true
+				pending = append(pending, c)
+			}
+		}
+	}
+
+	// make q stable with respect to the set of all states
+	for a := range m.action {
+		var marked []int
+		for x := 0; x < n; x++ {
+			for _, e := range m.succ[x] {
+				if e.action == a {
+					count[countKey{x, a, 0}]++
+				}
+			}
+			if count[countKey{x, a, 0}] > 0 {
+				marked = append(marked, x)
+			}
+		}
+		register(q.split(marked))
+	}
+
+	for len(pending) > 0 {
+		s := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		isPending[s] = false
+
+		// take the smaller of the first two blocks out of s
+		i := 0
+		if len(q.members[parts[s][1]]) < len(q.members[parts[s][0]]) {
+			i = 1
+		}
+		b := parts[s][i]
+		parts[s] = append(parts[s][:i], parts[s][i+1:]...)
+		if len(parts[s]) > 1 {
+			isPending[s] = true
+			pending = append(pending, s)
+		}
+		c := len(parts)
+		parts = append(parts, []int{b})
+		compound[b] = c
+		statesB := append([]int(nil), q.members[b]...)
+
+		for a := range m.action {
+			into := map[int]int{}
+			var pre []int
+			for _, y := range statesB {
+				for _, x := range pred[a][y] {
+					if into[x] == 0 {
+						pre = append(pre, x)
+					}
+					into[x]++
+				}
+			}
+			if len(pre) == 0 {
+				continue
+			}
+			register(q.split(pre))
+
+			// states whose a-edges into s all go to b
+			var onlyB []int
+			for _, x := range pre {
+				count[countKey{x, a, c}] = into[x]
+				if into[x] == count[countKey{x, a, s}] {
+					onlyB = append(onlyB, x)
+				}
+				count[countKey{x, a, s}] -= into[x]
+			}
+			register(q.split(onlyB))
+		}
+	}
+	return q
+}
+
+// BisimClass is a class of strongly bisimilar nodes, holding the nodes of the
+// class from each of the two graphs compared.
+type BisimClass struct {
+	G1, G2 []node
+}
+
+// Bisimulation returns the classes of strongly bisimilar nodes among the nodes
+// reachable from s1 in g1 and from s2 in g2. Epsilon edges are treated as an
+// ordinary, silent label. Classes are ordered by their smallest node, looking
+// at g1 first.
+func Bisimulation(g1 LGraph, s1 node, g2 LGraph, s2 node) []BisimClass {
+	m := newLTS([]LGraph{g1, g2}, []node{s1, s2})
+	q := m.bisimulation()
+
+	var classes []BisimClass
+	for _, members := range q.members {
+		if len(members) == 0 {
+			continue
+		}
+		var class BisimClass
+		for _, x := range members {
+			if m.graph[x] == 0 {
+				class.G1 = append(class.G1, m.node[x])
+			} else {
+				class.G2 = append(class.G2, m.node[x])
+			}
+		}
+		sortNodes(class.G1)
+		sortNodes(class.G2)
+		classes = append(classes, class)
+	}
+	first := func(c BisimClass) (int, node) {
+		if len(c.G1) > 0 {
+			return 0, c.G1[0]
+		}
+		return 1, c.G2[0]
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		gi, ni := first(classes[i])
+		gj, nj := first(classes[j])
+		return gi < gj || gi == gj && ni < nj
+	})
+	return classes
+}
+
+// Bisimilar returns true iff node s1 of g1 and node s2 of g2 are strongly
+// bisimilar.
+func Bisimilar(g1 LGraph, s1 node, g2 LGraph, s2 node) bool {
+	m := newLTS([]LGraph{g1, g2}, []node{s1, s2})
+	q := m.bisimulation()
+	return q.block[m.index[0][s1]] == q.block[m.index[1][s2]]
+}
+
+// Simulates returns true iff node s2 of g2 simulates node s1 of g1, that is,
+// every edge taken from s1 can be matched by an edge with the same label from
+// s2, leading again to a pair of nodes in the simulation.
+func Simulates(g1 LGraph, s1 node, g2 LGraph, s2 node) bool {
+	m := newLTS([]LGraph{g1, g2}, []node{s1, s2})
+	n := len(m.node)
+
+	// start from all pairs and remove those that cannot be matched until
+	// nothing changes
+	simulated := make([][]bool, n)
+	for p := range simulated {
+		simulated[p] = make([]bool, n)
+		for q := range simulated[p] {
+			simulated[p][q] = true
+		}
+	}
+	matched := func(p, q int) bool {
+		for _, pe := range m.succ[p] {
+			found := false
+			for _, qe := range m.succ[q] {
+				if pe.action == qe.action && simulated[pe.target][qe.target] {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+	for changed := true; changed; {
+		changed = false
+		for p := 0; p < n; p++ {
+			for q := 0; q < n; q++ {
+				if simulated[p][q] && !matched(p, q) {
+					simulated[p][q] = false
+					changed = true
+				}
+			}
+		}
+	}
+	return simulated[m.index[0][s1]][m.index[1][s2]]
+}
+
+// formula is a Hennessy-Milner logic formula built from true, negation,
+// conjunction and the diamond modality <a>φ.
+type formula struct {
+	op     formulaOp
+	action int
+	args   []formula
+}
+
+type formulaOp int
+
+const (
+	formulaTrue formulaOp = iota
+	formulaNot
+	formulaAnd
+	formulaDiamond
+)
+
+// holds returns true iff f holds at state x of m.
+func (m *lts) holds(f formula, x int) bool {
+	switch f.op {
+	case formulaNot:
+		return !m.holds(f.args[0], x)
+	case formulaAnd:
+		for _, arg := range f.args {
+			if !m.holds(arg, x) {
+				return false
+			}
+		}
+		return true
+	case formulaDiamond:
+		for _, e := range m.succ[x] {
+			if e.action == f.action && m.holds(f.args[0], e.target) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// format formats f, writing ! for negation, & for conjunction and <a> for the
+// diamond modality.
+func (m *lts) format(f formula) string {
+	switch f.op {
+	case formulaNot:
+		return "!" + m.format(f.args[0])
+	case formulaAnd:
+		args := make([]string, len(f.args))
+		for i, arg := range f.args {
+			args[i] = m.format(arg)
+		}
+		return "(" + strings.Join(args, " & ") + ")"
+	case formulaDiamond:
+		return "<" + m.label(f.action) + ">" + m.format(f.args[0])
+	default:
+		return "true"
+	}
+}
+
+// levels returns the partitions of the states of m into k-bisimilar classes
+// for k = 0, 1, ... until they no longer change. levels[k][x] is the class of
+// state x.
+func (m *lts) levels() [][]int {
+	n := len(m.node)
+	levels := [][]int{make([]int, n)}
+	for {
+		prev := levels[len(levels)-1]
+		ids := map[string]int{}
+		next := make([]int, n)
+		for x := 0; x < n; x++ {
+			var moves []string
+			seen := map[string]bool{}
+			for _, e := range m.succ[x] {
+				move := strconv.Itoa(e.action) + ":" + strconv.Itoa(prev[e.target])
+				if !seen[move] {
+					seen[move] = true
+					moves = append(moves, move)
+				}
+			}
+			sort.Strings(moves)
+			key := strconv.Itoa(prev[x]) + "|" + strings.Join(moves, ",")
+			if _, ok := ids[key]; !ok {
+				ids[key] = len(ids)
+			}
+			next[x] = ids[key]
+		}
+		if len(ids) == countClasses(prev) {
+			return levels
+		}
+		levels = append(levels, next)
+	}
+}
+
+func countClasses(level []int) int {
+	seen := map[int]bool{}
+	for _, c := range level {
+		seen[c] = true
+	}
+	return len(seen)
+}
+
+// distinguish returns a formula that holds at p but not at q, which must not
+// be bisimilar.
+func (m *lts) distinguish(levels [][]int, p, q int) formula {
+	k := 1
+	for levels[k][p] == levels[k][q] {
+		k++
+	}
+	prev := levels[k-1]
+
+	// look for a move of p that q cannot match up to k-1 steps
+	for _, pe := range m.succ[p] {
+		var conjuncts []formula
+		seen := map[string]bool{}
+		matched := false
+		for _, qe := range m.succ[q] {
+			if qe.action != pe.action {
+				continue
+			}
+			if prev[qe.target] == prev[pe.target] {
+				matched = true
+				break
+			}
+			f := m.distinguish(levels, pe.target, qe.target)
+			if key := m.format(f); !seen[key] {
+				seen[key] = true
+				conjuncts = append(conjuncts, f)
+			}
+		}
+		if matched {
+			continue
+		}
+		inner := formula{op: formulaTrue}
+		switch len(conjuncts) {
+		case 0:
+		case 1:
+			inner = conjuncts[0]
+		default:
+			inner = formula{op: formulaAnd, args: conjuncts}
+		}
+		return formula{op: formulaDiamond, action: pe.action, args: []formula{inner}}
+	}
+	// otherwise q has a move that p cannot match
+	return formula{op: formulaNot, args: []formula{m.distinguish(levels, q, p)}}
+}
+
+// Distinguish returns (F, true) if node s1 of g1 and node s2 of g2 are not
+// bisimilar, where F is a Hennessy-Milner logic formula that holds at s1 but
+// not at s2; else it returns ("", false). Formulas are written with true, !
+// for negation, & for conjunction and <a>F for "some edge labeled a leads to
+// a node where F holds". Epsilon edges are written <ε>, and a label standing
+// for a range of runes that behave the same is written as a rune set.
+func Distinguish(g1 LGraph, s1 node, g2 LGraph, s2 node) (string, bool) {
+	m := newLTS([]LGraph{g1, g2}, []node{s1, s2})
+	levels := m.levels()
+	p, q := m.index[0][s1], m.index[1][s2]
+	if last := levels[len(levels)-1]; last[p] == last[q] {
+		return "", false
+	}
+	return m.format(m.distinguish(levels, p, q)), true
+}
+
+func sortNodes(nodes []node) {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+}
@@ -0,0 +1,183 @@
+package lgraph
+
+import (
+	"reflect"
+	"testing"
+)
+
+// mkGraph returns the graph with the given edges. Nodes without outgoing
+// edges must be listed with an empty slice to exist.
+func mkGraph(graph map[node][]edge) LGraph {
+	return func(source node) ([]edge, bool) {
+		edges, exists := graph[source]
+		return mkCopy(edges, exists)
+	}
+}
+
+// a.(b + c)
+var gChoiceLate = mkGraph(map[node][]edge{
+	0: {{destination: 1, label: 'a'}},
+	1: {{destination: 2, label: 'b'}, {destination: 3, label: 'c'}},
+	2: {},
+	3: {},
+})
+
+// a.b + a.c
+var gChoiceEarly = mkGraph(map[node][]edge{
+	0: {{destination: 1, label: 'a'}, {destination: 2, label: 'a'}},
+	1: {{destination: 3, label: 'b'}},
+	2: {{destination: 4, label: 'c'}},
+	3: {},
+	4: {},
+})
+
+var gLoop = mkGraph(map[node][]edge{
+	0: {{destination: 0, label: 'a'}},
+})
+
+var gLoop2 = mkGraph(map[node][]edge{
+	0: {{destination: 1, label: 'a'}},
+	1: {{destination: 0, label: 'a'}},
+})
+
+var gRange = mkGraph(map[node][]edge{
+	0: {setEdge(1, "[a-c]")},
+	1: {},
+})
+
+var gRunes = mkGraph(map[node][]edge{
+	0: {{destination: 1, label: 'a'}, {destination: 1, label: 'b'}, {destination: 2, label: 'c'}},
+	1: {},
+	2: {},
+})
+
+var gSilent = mkGraph(map[node][]edge{
+	0: {epsilonEdge(1)},
+	1: {{destination: 2, label: 'a'}},
+	2: {},
+})
+
+func TestBisimilar(t *testing.T) {
+	tests := []struct {
+		graph1, graph2 LGraph
+		s1, s2         node
+		expected       bool
+	}{
+		{gChoiceLate, gChoiceEarly, 0, 0, false},
+		{gChoiceLate, gChoiceEarly, 1, 1, false},
+		{gChoiceLate, gChoiceEarly, 2, 4, true},
+		{gLoop, gLoop2, 0, 0, true},
+		{gLoop, gLoop2, 0, 1, true},
+		{gRange, gRunes, 0, 0, true},
+		{gRange, gChoiceLate, 0, 0, false},
+		{gSilent, gChoiceLate, 0, 0, false},
+		{gSilent, gSilent, 1, 0, false},
+		{g1, g1, 2, 6, true},
+		{g2, g2, 0, 0, true},
+		{g2, g3, 0, 0, false},
+	}
+	for i, test := range tests {
+		actual := Bisimilar(test.graph1, test.s1, test.graph2, test.s2)
+		if actual != test.expected {
+			t.Errorf("#%d: Bisimilar(%d, %d)=%t; want %t", i,
+				test.s1, test.s2, actual, test.expected)
+		}
+	}
+}
+
+func TestBisimulation(t *testing.T) {
+	actual := Bisimulation(gChoiceLate, 0, gChoiceEarly, 0)
+	expected := []BisimClass{
+		{[]node{0}, nil},
+		{[]node{1}, nil},
+		{[]node{2, 3}, []node{3, 4}},
+		{nil, []node{0}},
+		{nil, []node{1}},
+		{nil, []node{2}},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Bisimulation(gChoiceLate, gChoiceEarly)=%v; want %v", actual, expected)
+	}
+
+	actual = Bisimulation(gLoop, 0, gLoop2, 0)
+	expected = []BisimClass{{[]node{0}, []node{0, 1}}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Bisimulation(gLoop, gLoop2)=%v; want %v", actual, expected)
+	}
+}
+
+func TestSimulates(t *testing.T) {
+	tests := []struct {
+		graph1, graph2 LGraph
+		s1, s2         node
+		expected       bool
+	}{
+		{gChoiceEarly, gChoiceLate, 0, 0, true},
+		{gChoiceLate, gChoiceEarly, 0, 0, false},
+		{gLoop2, gLoop, 0, 0, true},
+		{gRange, gRunes, 0, 0, true},
+		{gRunes, gChoiceLate, 0, 0, false},
+		{gChoiceLate, gLoop, 0, 0, false},
+		{g1, g2, 0, 0, false},
+		{g2, g3, 0, 0, false},
+		{g1, g1, 0, 0, true},
+	}
+	for i, test := range tests {
+		actual := Simulates(test.graph1, test.s1, test.graph2, test.s2)
+		if actual != test.expected {
+			t.Errorf("#%d: Simulates(%d, %d)=%t; want %t", i,
+				test.s1, test.s2, actual, test.expected)
+		}
+	}
+}
+
+func TestDistinguish(t *testing.T) {
+	tests := []struct {
+		graph1, graph2 LGraph
+		s1, s2         node
+		expected       string
+		expectedExists bool
+	}{
+		{gChoiceLate, gChoiceEarly, 0, 0, "<a>(<c>true & <b>true)", true},
+		{gChoiceEarly, gChoiceLate, 0, 0, "<a>!<c>true", true},
+		{gLoop, gLoop2, 0, 0, "", false},
+		{gRange, gRunes, 0, 0, "", false},
+		{gSilent, gChoiceLate, 0, 0, "<ε>true", true},
+		{gChoiceLate, gRange, 0, 0, "!<b>true", true},
+	}
+	for i, test := range tests {
+		actual, exists := Distinguish(test.graph1, test.s1, test.graph2, test.s2)
+		if actual != test.expected || exists != test.expectedExists {
+			t.Errorf("#%d: Distinguish(%d, %d)=(%q, %t); want (%q, %t)", i,
+				test.s1, test.s2, actual, exists, test.expected, test.expectedExists)
+		}
+	}
+}
+
+func TestDistinguishHolds(t *testing.T) {
+	graphs := []LGraph{g1, g2, g3, gChoiceLate, gChoiceEarly, gRange, gRunes, gSilent}
+	for i, graph1 := range graphs {
+		for j, graph2 := range graphs {
+			m := newLTS([]LGraph{graph1, graph2}, []node{0, 0})
+			levels := m.levels()
+			last := levels[len(levels)-1]
+			q := m.bisimulation()
+			for p := range m.node {
+				for r := range m.node {
+					if (last[p] == last[r]) != (q.block[p] == q.block[r]) {
+						t.Errorf("graphs %d, %d: states %d and %d disagree on bisimilarity", i, j, p, r)
+						continue
+					}
+					if last[p] == last[r] {
+						continue
+					}
+					f := m.distinguish(levels, p, r)
+					if !m.holds(f, p) || m.holds(f, r) {
+						t.Errorf("graphs %d, %d: %s does not distinguish states %d and %d",
+							i, j, m.format(f), p, r)
+					}
+				}
+			}
+		}
+	}
+}
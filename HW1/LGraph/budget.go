@@ -0,0 +1,114 @@
+package lgraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// ErrBudgetExceeded is the error matched by every *BudgetError, so callers can
+// test for it with errors.Is.
+var ErrBudgetExceeded = errors.New("search budget exceeded")
+
+// Budget limits the work a search may do. A zero field means no limit.
+type Budget struct {
+	// MaxVisits is the number of nodes the search may visit.
+	MaxVisits uint64
+	// MaxMemory is the number of bytes the search may hold in its own data
+	// structures, as estimated by the search.
+	MaxMemory uint64
+}
+
+// Stats reports the work done by a search.
+type Stats struct {
+	Visits uint64
+	Memory uint64
+}
+
+// BudgetError is the error returned when a search runs out of budget. Stats
+// holds the work done up to that point.
+type BudgetError struct {
+	Resource string // "visits" or "memory"
+	Limit    uint64
+	Stats    Stats
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%s: %s limit %d reached after %d visits and %d bytes",
+		ErrBudgetExceeded, e.Resource, e.Limit, e.Stats.Visits, e.Stats.Memory)
+}
+
+// Is makes errors.Is(err, ErrBudgetExceeded) true for every *BudgetError.
+func (e *BudgetError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// Rough sizes of what the searches keep per visited state and per path.
+const (
+	runeSize  = uint64(unsafe.Sizeof(rune(0)))
+	edgeSize  = uint64(unsafe.Sizeof(edge{}))
+	stateSize = uint64(unsafe.Sizeof(node(0)) + unsafe.Sizeof("") + unsafe.Sizeof(uint(0)))
+)
+
+func stringSize(str string) uint64 {
+	return uint64(len(str))
+}
+
+// meter counts the work of a search against a context and a budget. Once it
+// has said to stop, err holds the reason.
+type meter struct {
+	ctx    context.Context
+	budget Budget
+	stats  Stats
+	err    error
+}
+
+func newMeter(ctx context.Context, budget Budget) *meter {
+	return &meter{ctx: ctx, budget: budget}
+}
+
+// visit records a visit to a node that keeps bytes of memory, and returns
+// false if the search must stop.
+func (m *meter) visit(bytes uint64) bool {
+	if m.err != nil {
+		return false
+	}
+	m.stats.Visits++
+	if err := m.ctx.Err(); err != nil {
+		m.err = err
+		return false
+	}
+	if m.budget.MaxVisits > 0 && m.stats.Visits > m.budget.MaxVisits {
+		m.err = &BudgetError{"visits", m.budget.MaxVisits, m.stats}
+		return false
+	}
+	return m.use(bytes)
+}
+
+// use records bytes more of memory kept by the search, and returns false if
+// the search must stop. The total saturates rather than wrapping around.
+func (m *meter) use(bytes uint64) bool {
+	if m.err != nil {
+		return false
+	}
+	m.stats.Memory += min(bytes, math.MaxUint64-m.stats.Memory)
+	if m.budget.MaxMemory > 0 && m.stats.Memory > m.budget.MaxMemory {
+		m.err = &BudgetError{"memory", m.budget.MaxMemory, m.stats}
+		return false
+	}
+	return true
+}
+
+// FindSequenceContext is like FindSequence but gives up when ctx is cancelled
+// or its deadline passes, in which case it returns ctx.Err().
+func FindSequenceContext(ctx context.Context, g1, g2 LGraph, s, t node, k uint) ([]rune, bool, error) {
+	return FindSequenceBudget(ctx, Budget{}, g1, g2, s, t, k)
+}
+
+// FindSequenceBudget is like FindSequenceContext but also gives up with a
+// *BudgetError once the search exceeds budget.
+func FindSequenceBudget(ctx context.Context, budget Budget, g1, g2 LGraph, s, t node, k uint) ([]rune, bool, error) {
+	return findSequence(newMeter(ctx, budget), g1, g2, s, t, k)
+}
@@ -0,0 +1,135 @@
+package lgraph
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// complete returns the complete graph on n nodes, where the edge from i to j
+// is labeled by one of three runes.
+func complete(n node) LGraph {
+	return func(source node) ([]edge, bool) {
+		if source >= n {
+			return nil, false
+		}
+		edges := make([]edge, n)
+		for j := range edges {
+			edges[j] = edge{destination: node(j), label: 'a' + rune((source+node(j))%3)}
+		}
+		return edges, true
+	}
+}
+
+func TestFindSequenceContext(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		graph1, graph2 LGraph
+		source, target node
+		sequenceLength uint
+	}{
+		{g1, g2, 0, 6, 5},
+		{g2, g1, 1, 1, 6},
+		{g1, g2, 0, 2, 2},
+		{g4, g5, 0, 4, 2},
+	} {
+		expected, expectedExists := FindSequence(test.graph1, test.graph2, test.source, test.target, test.sequenceLength)
+		actual, exists, err := FindSequenceContext(ctx, test.graph1, test.graph2, test.source, test.target, test.sequenceLength)
+		if err != nil || exists != expectedExists || !reflect.DeepEqual(actual, expected) {
+			t.Errorf("FindSequenceContext(%d, %d, %d)=(%q, %t, %v); want (%q, %t, nil)",
+				test.source, test.target, test.sequenceLength, actual, exists, err, expected, expectedExists)
+		}
+	}
+}
+
+func TestFindSequenceContextCancelled(t *testing.T) {
+	g := complete(12)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := FindSequenceContext(ctx, g, g, 0, 1, 30); err != context.Canceled {
+		t.Errorf("FindSequenceContext with cancelled context gives %v, expected %v", err, context.Canceled)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := FindSequenceContext(ctx, g, g, 0, 1, 1000); err != context.DeadlineExceeded {
+		t.Errorf("FindSequenceContext past deadline gives %v, expected %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestFindSequenceBudget(t *testing.T) {
+	g := complete(12)
+	tests := []struct {
+		budget   Budget
+		resource string
+	}{
+		{Budget{MaxVisits: 100}, "visits"},
+		{Budget{MaxMemory: 1000}, "memory"},
+		{Budget{MaxVisits: 1 << 40, MaxMemory: 1 << 12}, "memory"},
+		{Budget{MaxVisits: 10, MaxMemory: 1 << 40}, "visits"},
+	}
+	for i, test := range tests {
+		sequence, exists, err := FindSequenceBudget(context.Background(), test.budget, g, g, 0, 1, 100)
+		var budgetErr *BudgetError
+		if sequence != nil || exists || !errors.Is(err, ErrBudgetExceeded) || !errors.As(err, &budgetErr) {
+			t.Errorf("#%d: FindSequenceBudget(%+v)=(%q, %t, %v); want budget exceeded", i,
+				test.budget, sequence, exists, err)
+			continue
+		}
+		if budgetErr.Resource != test.resource || budgetErr.Stats.Visits == 0 {
+			t.Errorf("#%d: FindSequenceBudget(%+v) gives %+v; want %s exceeded", i,
+				test.budget, budgetErr, test.resource)
+		}
+		if test.budget.MaxVisits > 0 && budgetErr.Stats.Visits > test.budget.MaxVisits+1 {
+			t.Errorf("#%d: FindSequenceBudget(%+v) made %d visits", i,
+				test.budget, budgetErr.Stats.Visits)
+		}
+	}
+
+	// the memory of a sequence far too long to keep is charged as it grows
+	sink := mkGraph(map[node][]edge{0: {}})
+	sequence, exists, err := FindSequenceBudget(context.Background(), Budget{MaxMemory: 1000}, gLoop, sink, 0, 0, 1<<40)
+	var budgetErr *BudgetError
+	if sequence != nil || exists || !errors.As(err, &budgetErr) || budgetErr.Resource != "memory" {
+		t.Errorf("FindSequenceBudget(gLoop, 0, 0, 1<<40)=(%d runes, %t, %v); want memory exceeded", len(sequence), exists, err)
+	}
+
+	// a budget that is large enough does not change the answer
+	sequence, exists, err = FindSequenceBudget(context.Background(), Budget{MaxVisits: 1000, MaxMemory: 1 << 20}, g1, g2, 0, 6, 5)
+	if err != nil || !exists || !reflect.DeepEqual(sequence, []rune{'b', 'f', 'k', 'm', 'l'}) {
+		t.Errorf("FindSequenceBudget(0, 6, 5)=(%q, %t, %v); want (\"bfkml\", true, nil)", sequence, exists, err)
+	}
+}
+
+// A charge that would wrap the total around exceeds the budget.
+func TestMeterSaturates(t *testing.T) {
+	m := newMeter(context.Background(), Budget{MaxMemory: 1 << 40})
+	if !m.use(1 << 39) {
+		t.Fatalf("The meter stopped within its budget: %v", m.err)
+	}
+	if m.use(math.MaxUint64) || m.stats.Memory != math.MaxUint64 || !errors.Is(m.err, ErrBudgetExceeded) {
+		t.Errorf("The meter past its budget has %d bytes and %v; want %d and budget exceeded",
+			m.stats.Memory, m.err, uint64(math.MaxUint64))
+	}
+}
+
+func TestFindPathsContext(t *testing.T) {
+	g := complete(6)
+	paths, err := findPathsContext(context.Background(), Budget{MaxVisits: 50}, g, 0, 0, 5)
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("findPathsContext gives %v; want budget exceeded", err)
+	}
+	all := findPaths(g, 0, 0, 5)
+	if len(paths) >= len(all) || !reflect.DeepEqual(paths, all[:len(paths)]) {
+		t.Errorf("findPathsContext found %d paths, not a prefix of the %d paths", len(paths), len(all))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if paths, err := findPathsContext(ctx, Budget{}, g, 0, 0, 5); err != context.Canceled || len(paths) != 0 {
+		t.Errorf("findPathsContext with cancelled context gives (%d paths, %v)", len(paths), err)
+	}
+}
@@ -0,0 +1,325 @@
+package lgraph
+
+// kripke is the structure of the positions of the traces of a graph. Every
+// position stands for a labeled edge and a piece of its label set whose runes
+// all satisfy the same labels of a property, and leads to the positions of the
+// edges leaving the destination of the edge. Only the positions that some
+// infinite trace goes through are kept.
+type kripke struct {
+	witness []rune
+	succ    [][]int
+	pred    [][]int
+}
+
+// newKripke returns the positions of the traces of g from s whose labels are
+// split by labels, and the positions at which those traces start.
+func newKripke(g LGraph, s node, labels []runeSet) (*kripke, []int) {
+	type position struct {
+		from        node
+		edge, piece int
+	}
+	k := &kripke{}
+	ids := map[position]int{}
+	var destination []node
+	leaving := map[node][]int{}
+
+	// positions returns the positions of the edges leaving n
+	positions := func(n node) []int {
+		if result, seen := leaving[n]; seen {
+			return result
+		}
+		result := []int{}
+		for _, m := range closure(g, []node{n}) {
+			edges, _ := g(m)
+			for i, e := range edges {
+				if e.epsilon {
+					continue
+				}
+				for j, piece := range refine(e.accepts(), labels) {
+					pos := position{m, i, j}
+					id, seen := ids[pos]
+					if !seen {
+						id = len(k.witness)
+						ids[pos] = id
+						k.witness = append(k.witness, piece[0].lo)
+						destination = append(destination, e.destination)
+					}
+					result = append(result, id)
+				}
+			}
+		}
+		leaving[n] = result
+		return result
+	}
+
+	initial := positions(s)
+	for x := 0; x < len(k.witness); x++ {
+		k.succ = append(k.succ, positions(destination[x]))
+	}
+
+	// drop the positions from which every trace gets stuck
+	live := make([]bool, len(k.witness))
+	count := make([]int, len(k.witness))
+	k.pred = make([][]int, len(k.witness))
+	var queue []int
+	for x, succ := range k.succ {
+		live[x] = true
+		count[x] = len(succ)
+		for _, y := range succ {
+			k.pred[y] = append(k.pred[y], x)
+		}
+		if count[x] == 0 {
+			queue = append(queue, x)
+		}
+	}
+	for len(queue) > 0 {
+		x := queue[0]
+		queue = queue[1:]
+		live[x] = false
+		for _, p := range k.pred[x] {
+			if count[p]--; count[p] == 0 {
+				queue = append(queue, p)
+			}
+		}
+	}
+	keep := func(xs []int) []int {
+		var result []int
+		for _, x := range xs {
+			if live[x] {
+				result = append(result, x)
+			}
+		}
+		return result
+	}
+	for x := range k.succ {
+		k.succ[x] = keep(k.succ[x])
+		k.pred[x] = keep(k.pred[x])
+	}
+	return k, keep(initial)
+}
+
+// eval returns, for every position, whether p holds there. What it returns for
+// the positions that were dropped is meaningless.
+func (k *kripke) eval(p Property) ([]bool, error) {
+	result := make([]bool, len(k.witness))
+	switch p.op {
+	case propertyTrue:
+		for x := range result {
+			result[x] = true
+		}
+	case propertyLabel:
+		set, err := parseRuneSet(p.set)
+		if err != nil {
+			return nil, err
+		}
+		for x, r := range k.witness {
+			result[x] = set.contains(r)
+		}
+	case propertyNot, propertyAnd, propertyOr:
+		args := make([][]bool, len(p.args))
+		for i, arg := range p.args {
+			var err error
+			if args[i], err = k.eval(arg); err != nil {
+				return nil, err
+			}
+		}
+		for x := range result {
+			switch p.op {
+			case propertyNot:
+				result[x] = !args[0][x]
+			case propertyAnd:
+				result[x] = args[0][x] && args[1][x]
+			default:
+				result[x] = args[0][x] || args[1][x]
+			}
+		}
+	case propertyExists, propertyForAll:
+		return k.quantified(p.op == propertyExists, p.args[0])
+	default:
+		return nil, ErrNotCTL
+	}
+	return result, nil
+}
+
+// quantified returns where some trace, if exists is true, or every trace, if
+// it is false, satisfies the temporal property p.
+func (k *kripke) quantified(exists bool, p Property) ([]bool, error) {
+	if !p.temporal() {
+		return nil, ErrNotCTL
+	}
+	args := make([][]bool, len(p.args))
+	for i, arg := range p.args {
+		var err error
+		if args[i], err = k.eval(arg); err != nil {
+			return nil, err
+		}
+	}
+	all := make([]bool, len(k.witness))
+	for x := range all {
+		all[x] = true
+	}
+
+	switch {
+	case p.op == propertyNext:
+		return k.next(exists, args[0]), nil
+	case p.op == propertyUntil && exists:
+		return k.existsUntil(args[0], args[1]), nil
+	case p.op == propertyUntil:
+		return k.forAllUntil(args[0], args[1]), nil
+	case p.op == propertyEventually && exists:
+		return k.existsUntil(all, args[0]), nil
+	case p.op == propertyEventually:
+		return k.forAllUntil(all, args[0]), nil
+	case p.op == propertyAlways && exists:
+		return k.existsAlways(args[0]), nil
+	case p.op == propertyAlways:
+		// AG p = !E(true U !p)
+		return not(k.existsUntil(all, not(args[0]))), nil
+	case p.op == propertyRelease && exists:
+		// E(p R q) = !A(!p U !q)
+		return not(k.forAllUntil(not(args[0]), not(args[1]))), nil
+	default:
+		// A(p R q) = !E(!p U !q)
+		return not(k.existsUntil(not(args[0]), not(args[1]))), nil
+	}
+}
+
+func not(set []bool) []bool {
+	result := make([]bool, len(set))
+	for x := range set {
+		result[x] = !set[x]
+	}
+	return result
+}
+
+// next returns where some or every next position is in set.
+func (k *kripke) next(exists bool, set []bool) []bool {
+	result := make([]bool, len(set))
+	for x, succ := range k.succ {
+		if len(succ) == 0 {
+			continue
+		}
+		result[x] = !exists
+		for _, y := range succ {
+			if set[y] == exists {
+				result[x] = exists
+				break
+			}
+		}
+	}
+	return result
+}
+
+// existsUntil returns the least set containing q and every position of p with
+// a next position in the set.
+func (k *kripke) existsUntil(p, q []bool) []bool {
+	result := make([]bool, len(p))
+	var queue []int
+	for x := range q {
+		if q[x] && len(k.succ[x]) > 0 {
+			result[x] = true
+			queue = append(queue, x)
+		}
+	}
+	for len(queue) > 0 {
+		x := queue[0]
+		queue = queue[1:]
+		for _, y := range k.pred[x] {
+			if p[y] && !result[y] {
+				result[y] = true
+				queue = append(queue, y)
+			}
+		}
+	}
+	return result
+}
+
+// forAllUntil returns the least set containing q and every position of p
+// whose next positions are all in the set.
+func (k *kripke) forAllUntil(p, q []bool) []bool {
+	result := make([]bool, len(p))
+	left := make([]int, len(p))
+	var queue []int
+	for x := range q {
+		left[x] = len(k.succ[x])
+		if q[x] && left[x] > 0 {
+			result[x] = true
+			queue = append(queue, x)
+		}
+	}
+	for len(queue) > 0 {
+		x := queue[0]
+		queue = queue[1:]
+		for _, y := range k.pred[x] {
+			if left[y]--; left[y] == 0 && p[y] && !result[y] {
+				result[y] = true
+				queue = append(queue, y)
+			}
+		}
+	}
+	return result
+}
+
+// existsAlways returns the greatest set of positions of p that all have a next
+// position in the set.
+func (k *kripke) existsAlways(p []bool) []bool {
+	result := make([]bool, len(p))
+	for x := range p {
+		result[x] = p[x] && len(k.succ[x]) > 0
+	}
+	left := make([]int, len(p))
+	var queue []int
+	for x := range p {
+		if !result[x] {
+			continue
+		}
+		for _, y := range k.succ[x] {
+			if result[y] {
+				left[x]++
+			}
+		}
+		if left[x] == 0 {
+			queue = append(queue, x)
+		}
+	}
+	for len(queue) > 0 {
+		x := queue[0]
+		queue = queue[1:]
+		result[x] = false
+		for _, y := range k.pred[x] {
+			if result[y] {
+				if left[y]--; left[y] == 0 {
+					queue = append(queue, y)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// CheckCTL returns true iff p holds at every position at which an infinite
+// trace from node s in graph g can start, which makes p hold if g has no
+// such trace. Properties are computed as fixpoints over the positions of the
+// traces, so that for instance ForAll(Always(p)) holds iff p holds at every
+// position of every trace. It returns ErrNotCTL if p is not in CTL, or
+// ErrRuneSet if a label of p is not a valid rune set.
+func CheckCTL(g LGraph, s node, p Property) (bool, error) {
+	labels, err := p.labelSets(nil)
+	if err != nil {
+		return false, err
+	}
+	if _, exists := g(s); !exists {
+		return true, nil
+	}
+	k, initial := newKripke(g, s, labels)
+	sat, err := k.eval(p)
+	if err != nil {
+		return false, err
+	}
+	for _, x := range initial {
+		if !sat[x] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,99 @@
+package lgraph
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckCTL(t *testing.T) {
+	tests := []struct {
+		graph    LGraph
+		source   node
+		property Property
+		holds    bool
+	}{
+		{gLight, 0, ForAll(Always(Exists(Eventually(pr)))), true},
+		{gLight, 0, ForAll(Always(Implies(pg, ForAll(Next(py))))), true},
+		{gLight, 0, Exists(Eventually(pr)), true},
+		{gLight, 0, Exists(Always(pg)), false},
+		{gLight, 0, ForAll(Eventually(pr)), true},
+		{gLight, 0, ForAll(Until(Not(pr), pr)), true},
+		{gCommit, 0, Exists(Eventually(pc)), true},
+		{gCommit, 0, ForAll(Eventually(pc)), false},
+		{gCommit, 0, Exists(Always(pa)), false},
+		{gCommit, 0, Exists(Always(Or(pa, pc))), false},
+		{gCommit, 0, Or(pb, Exists(Always(Or(pa, pc)))), true},
+		{gCommit, 0, ForAll(Always(pa)), false},
+		{gCommit, 0, Exists(Until(pa, pb)), true},
+		{gCommit, 0, ForAll(Until(pa, pb)), false},
+		{gCommit, 0, ForAll(Always(Implies(pc, ForAll(Next(pc))))), true},
+		{gCommit, 0, Exists(Next(pb)), false},
+		{gCommit, 0, Or(pa, Exists(Next(pc))), true},
+		{gCommit, 0, Exists(Release(pb, pa)), false},
+		{gCommit, 0, Or(pb, Exists(Release(pb, pa))), true},
+		{gCommit, 0, ForAll(Release(pb, pa)), false},
+		{gCommit, 0, ForAll(Release(pb, Or(pa, pb))), true},
+		{gStuck, 0, ForAll(Always(Not(pb))), true},
+		{gStuck, 0, Exists(Eventually(pb)), false},
+		{gSetLoop, 0, ForAll(Always(Label("[a-c]"))), true},
+		{gSetLoop, 0, Exists(Always(pb)), false},
+		{gSetLoop, 0, ForAll(Always(Exists(Next(pb)))), true},
+		{gSilentLoop2, 0, ForAll(Always(pa)), true},
+		{gLight, 7, ForAll(Always(pa)), true},
+		{g2, 0, Exists(Always(Exists(Eventually(Label("[l]"))))), false},
+		{g2, 0, ForAll(Always(Exists(Eventually(Label("[a-e]"))))), true},
+	}
+	for i, test := range tests {
+		holds, err := CheckCTL(test.graph, test.source, test.property)
+		if err != nil || holds != test.holds {
+			t.Errorf("#%d: CheckCTL(%d, %v)=(%t, %v); want (%t, nil)", i, test.source, test.property, holds, err, test.holds)
+		}
+	}
+}
+
+// On properties that are both in CTL and LTL the checks agree.
+func TestCheckCTLAgreesWithLTL(t *testing.T) {
+	labels := []Property{pa, pb, pc, Label("[a-c]"), Label("[d-z]")}
+	graphs := []LGraph{gLight, gCommit, gStuck, gSetLoop, gSilentLoop2, g1, g2, g3}
+	for i, graph := range graphs {
+		for _, p := range labels {
+			for _, q := range labels {
+				pairs := [][2]Property{
+					{ForAll(Always(p)), Always(p)},
+					{ForAll(Eventually(p)), Eventually(p)},
+					{ForAll(Next(p)), Next(p)},
+					{ForAll(Until(p, q)), Until(p, q)},
+					{ForAll(Release(p, q)), Release(p, q)},
+					{ForAll(Always(ForAll(Eventually(p)))), Always(Eventually(p))},
+				}
+				for _, pair := range pairs {
+					for s := node(0); s < 8; s++ {
+						ctl, err1 := CheckCTL(graph, s, pair[0])
+						ltl, err2 := CheckLTL(graph, s, pair[1])
+						if err1 != nil || err2 != nil || ctl != ltl {
+							t.Errorf("graph %d, node %d: CheckCTL(%v)=(%t, %v), CheckLTL(%v)=(%t, %v)",
+								i, s, pair[0], ctl, err1, pair[1], ltl, err2)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCheckCTLErrors(t *testing.T) {
+	tests := []struct {
+		property Property
+		err      error
+	}{
+		{Always(pa), ErrNotCTL},
+		{Exists(pa), ErrNotCTL},
+		{Exists(Always(Eventually(pa))), ErrNotCTL},
+		{ForAll(Next(Label("[a"))), ErrRuneSet},
+	}
+	for i, test := range tests {
+		if _, err := CheckCTL(gLight, 0, test.property); !errors.Is(err, test.err) {
+			t.Errorf("#%d: CheckCTL(%v) gives error %v; want %v", i, test.property, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package lgraph
+
+// FindDistinguishing returns (S, len(S), true) if there is a sequence S from
+// node s to node t in graph g1 that is not a sequence from s to t in graph g2,
+// where S is as short as possible; else every sequence of g1 from s to t is
+// also one of g2, and it returns (nil, 0, false). Epsilon edges do not count
+// towards the length. Both graphs must be finite from s on, since the search
+// only stops early when it finds S.
+//
+// Unlike FindSequence, the length does not have to be guessed: the search runs
+// over pairs of a node of g1 and the set of nodes of g2 that the same sequence
+// reaches, in order of the length of the sequence.
+func FindDistinguishing(g1, g2 LGraph, s, t node) ([]rune, uint, bool) {
+	sequence, length, exists, _ := cheapestSequence(g1, g2, s, t, func(e edge) int {
+		if e.epsilon {
+			return 0
+		}
+		return 1
+	})
+	return sequence, uint(length), exists
+}
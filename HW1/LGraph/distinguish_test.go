@@ -0,0 +1,57 @@
+package lgraph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindDistinguishing(t *testing.T) {
+	tests := []struct {
+		graph1, graph2         LGraph
+		source, target         node
+		expectedSequence       []rune
+		expectedLength         uint
+		expectedSequenceExists bool
+	}{
+		{g1, g2, 0, 6, []rune{'b', 'f', 'k', 'm', 'l'}, 5, true},
+		{g2, g1, 1, 1, []rune{'c', 'e', 'b'}, 3, true},
+		{g2, g1, 4, 0, []rune{'h', 'c', 'e'}, 3, true},
+		{g1, g2, 7, 7, []rune{}, 0, true},
+		{g1, g2, 0, 2, nil, 0, false},
+		{g1, g1, 0, 6, nil, 0, false},
+		{g3, g2, 0, 4, []rune{'o'}, 1, true},
+		{g4, g5, 0, 4, []rune{'n', 'x'}, 2, true},
+		{g5, g4, 0, 2, nil, 0, false},
+		{g1, g2, 17, 0, nil, 0, false},
+	}
+	for i, test := range tests {
+		sequence, length, exists := FindDistinguishing(test.graph1, test.graph2, test.source, test.target)
+		if exists != test.expectedSequenceExists || length != test.expectedLength ||
+			!reflect.DeepEqual(sequence, test.expectedSequence) {
+			t.Errorf("#%d: FindDistinguishing(%d, %d)=(%q, %d, %t); want (%q, %d, %t)", i,
+				test.source, test.target, sequence, length, exists,
+				test.expectedSequence, test.expectedLength, test.expectedSequenceExists)
+		}
+	}
+}
+
+// FindDistinguishing finds the least k for which FindSequence finds a sequence.
+func TestFindDistinguishingAgreesWithFindSequence(t *testing.T) {
+	graphs := []LGraph{g1, g2, g3}
+	for i, graph1 := range graphs {
+		for j, graph2 := range graphs {
+			for s := node(0); s < 8; s++ {
+				for target := node(0); target < 8; target++ {
+					_, length, exists := FindDistinguishing(graph1, graph2, s, target)
+					for k := uint(0); k < 8; k++ {
+						_, found := FindSequence(graph1, graph2, s, target, k)
+						if found && (!exists || k < length) || exists && k == length && !found {
+							t.Errorf("graphs %d, %d: FindDistinguishing(%d, %d) gives length %d (%t), FindSequence with k=%d gives %t",
+								i, j, s, target, length, exists, k, found)
+						}
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package lgraph
+
+import (
+	"errors"
+	"sort"
+	"unicode/utf8"
+)
+
+// ErrRuneSet is the error value returned by parseRuneSet if the string is not
+// a valid rune set such as "[a-z_]".
+var ErrRuneSet = errors.New("invalid rune set")
+
+// runeRange is the closed interval of runes [lo, hi].
+type runeRange struct {
+	lo, hi rune
+}
+
+// runeSet is a sorted list of non-overlapping, non-adjacent rune ranges.
+type runeSet []runeRange
+
+// parseRuneSet parses a bracketed rune set in the usual regular expression
+// syntax, e.g. "[a-z]" or "[a-cx_]". A backslash escapes the next rune.
+// Negated sets are not supported since the alphabet is not fixed.
+func parseRuneSet(str string) (runeSet, error) {
+	runes := []rune(str)
+	if len(runes) < 2 || runes[0] != '[' || runes[len(runes)-1] != ']' {
+		return nil, ErrRuneSet
+	}
+	runes = runes[1 : len(runes)-1]
+	if len(runes) == 0 || runes[0] == '^' {
+		return nil, ErrRuneSet
+	}
+
+	// next returns the rune at position i, honouring escapes, and the
+	// position after it
+	next := func(i int) (rune, int, error) {
+		if runes[i] == '\\' {
+			if i+1 == len(runes) {
+				return 0, 0, ErrRuneSet
+			}
+			return runes[i+1], i + 2, nil
+		}
+		return runes[i], i + 1, nil
+	}
+
+	var set runeSet
+	for i := 0; i < len(runes); {
+		lo, j, err := next(i)
+		if err != nil {
+			return nil, err
+		}
+		hi := lo
+		// a dash at either end of the set is a literal dash
+		if j+1 < len(runes) && runes[j] == '-' {
+			if hi, j, err = next(j + 1); err != nil {
+				return nil, err
+			}
+			if hi < lo {
+				return nil, ErrRuneSet
+			}
+		}
+		set = append(set, runeRange{lo, hi})
+		i = j
+	}
+	return set.normalize(), nil
+}
+
+// mustRuneSet is like parseRuneSet but panics if str is not a valid rune set.
+func mustRuneSet(str string) runeSet {
+	set, err := parseRuneSet(str)
+	if err != nil {
+		panic(err)
+	}
+	return set
+}
+
+// normalize sorts the ranges of set and merges overlapping or adjacent ones.
+func (set runeSet) normalize() runeSet {
+	if len(set) == 0 {
+		return nil
+	}
+	sorted := make(runeSet, len(set))
+	copy(sorted, set)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].lo < sorted[j].lo })
+
+	merged := runeSet{sorted[0]}
+	for _, r := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if r.lo <= last.hi+1 {
+			if r.hi > last.hi {
+				last.hi = r.hi
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// contains returns true iff r is a member of set.
+func (set runeSet) contains(r rune) bool {
+	i := sort.Search(len(set), func(i int) bool { return set[i].hi >= r })
+	return i < len(set) && set[i].lo <= r
+}
+
+// String formats set back into the bracketed syntax accepted by parseRuneSet.
+func (set runeSet) String() string {
+	buf := []rune{'['}
+	write := func(r rune) {
+		switch r {
+		case '\\', ']', '-', '^':
+			buf = append(buf, '\\')
+		}
+		buf = append(buf, r)
+	}
+	for _, r := range set {
+		write(r.lo)
+		if r.hi != r.lo {
+			buf = append(buf, '-')
+			write(r.hi)
+		}
+	}
+	return string(append(buf, ']'))
+}
+
+// epsilonEdge returns a silent edge to destination.
+func epsilonEdge(destination node) edge {
+	return edge{destination: destination, epsilon: true}
+}
+
+// setEdge returns an edge to destination that accepts any rune of the set
+// described by str, e.g. "[a-z]". It panics if str is not a valid rune set.
+func setEdge(destination node, str string) edge {
+	return edge{destination: destination, labels: mustRuneSet(str)}
+}
+
+// accepts returns the set of runes that e accepts. Epsilon edges accept none.
+func (e edge) accepts() runeSet {
+	switch {
+	case e.epsilon:
+		return nil
+	case len(e.labels) > 0:
+		return e.labels
+	default:
+		return runeSet{{e.label, e.label}}
+	}
+}
+
+// labelStep is one way of following a set of labels from a set of nodes:
+// reading witness leads to the set of nodes next.
+type labelStep struct {
+	witness rune
+	next    []node
+}
+
+// splitLabels partitions the runes of want by where the labeled edges leaving
+// the nodes of from in g lead, and returns one step per distinct successor
+// set. Steps are ordered by their witness rune, which is the smallest rune
+// leading to that successor set. The successor sets are epsilon-closed.
+func splitLabels(g LGraph, from []node, want runeSet) []labelStep {
+	classes := labelClasses(g, from, want)
+	steps := make([]labelStep, len(classes))
+	for i, class := range classes {
+		steps[i] = labelStep{class.runes[0].lo, class.next}
+	}
+	return steps
+}
+
+// labelClass is a set of runes that all lead from a set of nodes to the same
+// set of nodes next.
+type labelClass struct {
+	runes runeSet
+	next  []node
+}
+
+// labelClasses partitions the runes of want by where the labeled edges leaving
+// the nodes of from in g lead. Classes are ordered by their smallest rune, and
+// the successor sets are epsilon-closed. Runes that lead nowhere form a class
+// with an empty successor set.
+func labelClasses(g LGraph, from []node, want runeSet) []labelClass {
+	var edges []edge
+	for _, n := range from {
+		out, _ := g(n)
+		for _, e := range out {
+			if !e.epsilon {
+				edges = append(edges, e)
+			}
+		}
+	}
+
+	// every rune at which some edge starts or stops accepting is a cut point
+	var cuts []rune
+	for _, e := range edges {
+		for _, r := range e.accepts() {
+			cuts = append(cuts, r.lo)
+			if r.hi < utf8.MaxRune {
+				cuts = append(cuts, r.hi+1)
+			}
+		}
+	}
+	sort.Slice(cuts, func(i, j int) bool { return cuts[i] < cuts[j] })
+
+	var classes []labelClass
+	index := map[string]int{}
+	for _, r := range want {
+		lo := r.lo
+		for lo <= r.hi {
+			// the interval runs up to the next cut point inside r
+			hi := r.hi
+			i := sort.Search(len(cuts), func(i int) bool { return cuts[i] > lo })
+			if i < len(cuts) && cuts[i]-1 < hi {
+				hi = cuts[i] - 1
+			}
+
+			var next []node
+			for _, e := range edges {
+				if e.accepts().contains(lo) {
+					next = append(next, e.destination)
+				}
+			}
+			next = closure(g, next)
+			key := nodeSetKey(next)
+			if j, seen := index[key]; seen {
+				classes[j].runes = append(classes[j].runes, runeRange{lo, hi})
+			} else {
+				index[key] = len(classes)
+				classes = append(classes, labelClass{runeSet{{lo, hi}}, next})
+			}
+			if hi == r.hi {
+				break
+			}
+			lo = hi + 1
+		}
+	}
+	for i := range classes {
+		classes[i].runes = classes[i].runes.normalize()
+	}
+	return classes
+}
+
+// size returns the number of runes in set.
+func (set runeSet) size() int64 {
+	var n int64
+	for _, r := range set {
+		n += int64(r.hi-r.lo) + 1
+	}
+	return n
+}
+
+// nth returns the rune of set at position i, counting from zero.
+func (set runeSet) nth(i int64) rune {
+	for _, r := range set {
+		if n := int64(r.hi-r.lo) + 1; i >= n {
+			i -= n
+		} else {
+			return r.lo + rune(i)
+		}
+	}
+	panic("rune set index out of range")
+}
+
+// union returns the set of runes in any of the sets.
+func union(sets ...runeSet) runeSet {
+	var all runeSet
+	for _, set := range sets {
+		all = append(all, set...)
+	}
+	return all.normalize()
+}
+
+// minus returns the runes of a that are not in b.
+func minus(a, b runeSet) runeSet {
+	var result runeSet
+	j := 0
+	for _, r := range a {
+		lo := r.lo
+		for ; j < len(b) && b[j].hi < lo; j++ {
+		}
+		for k := j; k < len(b) && b[k].lo <= r.hi; k++ {
+			if b[k].lo > lo {
+				result = append(result, runeRange{lo, b[k].lo - 1})
+			}
+			lo = b[k].hi + 1
+		}
+		if lo <= r.hi {
+			result = append(result, runeRange{lo, r.hi})
+		}
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package lgraph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRuneSet(t *testing.T) {
+	tests := []struct {
+		str      string
+		expected runeSet
+		err      error
+	}{
+		{"[a]", runeSet{{'a', 'a'}}, nil},
+		{"[a-z]", runeSet{{'a', 'z'}}, nil},
+		{"[a-cx_]", runeSet{{'_', '_'}, {'a', 'c'}, {'x', 'x'}}, nil},
+		{"[a-cb-f]", runeSet{{'a', 'f'}}, nil},
+		{"[abc]", runeSet{{'a', 'c'}}, nil},
+		{"[-a]", runeSet{{'-', '-'}, {'a', 'a'}}, nil},
+		{"[a-]", runeSet{{'-', '-'}, {'a', 'a'}}, nil},
+		{`[\]\\]`, runeSet{{'\\', ']'}}, nil},
+		{"[α-ω]", runeSet{{'α', 'ω'}}, nil},
+		{"", nil, ErrRuneSet},
+		{"a-z", nil, ErrRuneSet},
+		{"[]", nil, ErrRuneSet},
+		{"[^a]", nil, ErrRuneSet},
+		{"[z-a]", nil, ErrRuneSet},
+		{`[a\]`, nil, ErrRuneSet},
+		{`[a-\]`, nil, ErrRuneSet},
+	}
+	for i, test := range tests {
+		actual, err := parseRuneSet(test.str)
+		if err != test.err || !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("#%d: parseRuneSet(%q)=(%v, %v); want (%v, %v)", i,
+				test.str, actual, err, test.expected, test.err)
+		}
+		if err == nil {
+			if again := mustRuneSet(actual.String()); !reflect.DeepEqual(again, actual) {
+				t.Errorf("#%d: parseRuneSet(%q) does not round trip: %v", i,
+					actual.String(), again)
+			}
+		}
+	}
+}
+
+func TestRuneSetContains(t *testing.T) {
+	set := mustRuneSet("[0-9a-fx]")
+	for _, r := range "0123456789abcdefx" {
+		if !set.contains(r) {
+			t.Errorf("%v.contains(%q)=false; want true", set, r)
+		}
+	}
+	for _, r := range "/:`gwyzA" {
+		if set.contains(r) {
+			t.Errorf("%v.contains(%q)=true; want false", set, r)
+		}
+	}
+}
+
+func TestSplitLabels(t *testing.T) {
+	tests := []struct {
+		graph    LGraph
+		from     []node
+		want     string
+		expected []labelStep
+	}{
+		{g5, []node{0}, "[a-z]", []labelStep{
+			{'a', []node{0, 2}},
+			{'n', nil},
+			{'x', []node{3}},
+		}},
+		{g5, []node{0, 2}, "[x]", []labelStep{
+			{'x', []node{3, 4}},
+		}},
+		{g4, []node{0, 1}, "[A-Z0-9]", []labelStep{
+			{'0', nil},
+		}},
+		{g4, nil, "[a]", []labelStep{
+			{'a', nil},
+		}},
+		{g1, []node{0}, "[a-c]", []labelStep{
+			{'a', []node{0}},
+			{'b', []node{1}},
+			{'c', nil},
+		}},
+	}
+	for i, test := range tests {
+		actual := splitLabels(test.graph, test.from, mustRuneSet(test.want))
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("#%d: splitLabels(%v, %s)=%v; want %v", i,
+				test.from, test.want, actual, test.expected)
+		}
+	}
+}
+
+func TestMustRuneSetPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("mustRuneSet(%q) did not panic", "[]")
+		}
+	}()
+	mustRuneSet("[]")
+}
@@ -1,10 +1,25 @@
 package lgraph
 
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
 type node uint
 
+// edge is an outgoing edge of a node. By default the edge is labeled by the
+// single rune label. An epsilon edge is silent: it is taken without reading a
+// label. An edge with a non-empty labels set accepts any rune of the set.
+// The weight is the cost of taking the edge in the weighted searches; the
+// other searches ignore it.
 type edge struct {
 	destination node
 	label       rune
+	epsilon     bool
+	labels      runeSet
+	weight      int
 }
 
 // LGraph is a function representing a directed labeled graph. If the node exists
@@ -14,65 +29,207 @@ type LGraph func(node) ([]edge, bool)
 
 // FindSequence returns (S, true) if there is a sequence S of length k from node
 // s to node t in graph g1 and S is not a sequence from s to t in graph g2; else
-// it returns (nil, false).
+// it returns (nil, false). Epsilon edges do not count towards the length k.
+// When a set-labeled edge of g1 is used, S holds a concrete witness rune of the
+// set for which the sequence is not in g2.
 func FindSequence(g1, g2 LGraph, s, t node, k uint) ([]rune, bool) {
-	// TODO: Complete the function.
-	panic("TODO: implement this!")
-	pathG1 := findPaths(g1, s, t, k) // find all paths in g1 from s to t with len k
-	pathG2 := findPaths(g2, s, t, k) // find all paths in g2 from s to t with len k
-
-	// if there is a path in G1 also in G2 with the same len k, then return false
-	for _, path1 := range pathG1 {
-		for _, path2 := range pathG2 {
-			if path1 == path2 {
-				return nil, false
+	// without a budget or a cancellable context the search cannot fail
+	sequence, exists, _ := findSequence(newMeter(context.Background(), Budget{}), g1, g2, s, t, k)
+	return sequence, exists
+}
+
+// findSequence implements FindSequence, reporting every node visit to m and
+// giving up with m's error once m says to stop.
+func findSequence(m *meter, g1, g2 LGraph, s, t node, k uint) ([]rune, bool, error) {
+	if _, exists := g1(s); !exists {
+		return nil, false, nil
+	}
+	var start []node
+	if _, exists := g2(s); exists {
+		start = closure(g2, []node{s})
+	}
+	// nodes of g1 that cannot reach t in the steps left are not worth visiting
+	distance := distancesTo(m, g1, s, t, k)
+	if m.err != nil {
+		return nil, false, m.err
+	}
+
+	// walk g1 while tracking every node of g2 that the same prefix reaches
+	type state struct {
+		current node
+		others  string
+		len     uint
+	}
+	visited := map[state]bool{}
+	// the sequence grows with the steps taken, as k may be far longer than
+	// any sequence there is, and charges m for every rune it grows by
+	sequence := []rune{}
+	charged := uint(0)
+
+	var dfs func(current node, others []node, len uint) bool
+	dfs = func(current node, others []node, len uint) bool {
+		if d, ok := distance[current]; !ok || d > k-len {
+			return false
+		}
+		key := state{current, nodeSetKey(others), len}
+		if visited[key] {
+			return false
+		}
+		visited[key] = true
+		if !m.visit(stateSize + stringSize(key.others)) {
+			return false
+		}
+
+		if len == k && current == t && !containsNode(others, t) {
+			return true
+		}
+
+		edges, _ := g1(current)
+		for _, edge := range edges {
+			if m.err != nil {
+				return false
+			}
+			if edge.epsilon {
+				if dfs(edge.destination, others, len) {
+					return true
+				}
+				continue
+			}
+			if len == k {
+				continue
+			}
+			if len == charged {
+				if !m.use(runeSize) {
+					return false
+				}
+				charged++
+			}
+			for _, step := range splitLabels(g2, others, edge.accepts()) {
+				sequence = append(sequence[:len], step.witness)
+				if dfs(edge.destination, step.next, len+1) {
+					return true
+				}
+				sequence = sequence[:len]
+			}
+		}
+		return false
+	}
+	if dfs(s, start, 0) {
+		return sequence, true, nil
+	}
+	return nil, false, m.err
+}
+
+// closure returns the sorted set of nodes reachable from nodes in g by epsilon
+// edges only, including the nodes themselves.
+func closure(g LGraph, nodes []node) []node {
+	seen := map[node]bool{}
+	var result []node
+	stack := append([]node(nil), nodes...)
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		result = append(result, n)
+
+		edges, _ := g(n)
+		for _, edge := range edges {
+			if edge.epsilon && !seen[edge.destination] {
+				stack = append(stack, edge.destination)
 			}
 		}
 	}
-	// otherwise, return true
-	return []rune(pathG1[0]), true
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
 }
 
-// helper function
-func findPaths(g LGraph, s, t node, k uint) []string {
-	var paths []string // a slice that store all path
+// containsNode returns true iff n is in the sorted set of nodes.
+func containsNode(nodes []node, n node) bool {
+	i := sort.Search(len(nodes), func(i int) bool { return nodes[i] >= n })
+	return i < len(nodes) && nodes[i] == n
+}
+
+// nodeSetKey encodes a sorted set of nodes as a string usable as a map key.
+func nodeSetKey(nodes []node) string {
+	var b strings.Builder
+	for i, n := range nodes {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatUint(uint64(n), 10))
+	}
+	return b.String()
+}
+
+// findPaths returns every path of k labeled edges from s to t in g, in
+// depth-first order. Epsilon edges appear in the returned paths but do not
+// count towards k; a path never repeats a node between two labeled edges, so
+// epsilon cycles are not followed.
+func findPaths(g LGraph, s, t node, k uint) [][]edge {
+	paths, _ := findPathsContext(context.Background(), Budget{}, g, s, t, k)
+	return paths
+}
+
+// findPathsContext is like findPaths but stops early when ctx is done or the
+// budget is exceeded, returning the paths found so far and the reason.
+func findPathsContext(ctx context.Context, budget Budget, g LGraph, s, t node, k uint) ([][]edge, error) {
+	m := newMeter(ctx, budget)
+	var paths [][]edge // a slice that store all path
+	var path []edge
+	// nodes visited since the last labeled edge
+	silent := map[node]bool{}
 	// using dfs to find the path from s to t
-	var dfs func(current node, len uint, path string)
-	dfs = func(current node, len uint, path string) {
+	var dfs func(current node, length uint)
+	dfs = func(current node, length uint) {
 		// base case
-		if len > k {
+		if length > k || silent[current] || m.err != nil {
 			return
 		}
 		// reach the last node
-		if len == k && current == t {
-			paths = append(paths, path)
+		if length == k && current == t {
+			if m.use(uint64(len(path)) * edgeSize) {
+				paths = append(paths, append([]edge(nil), path...))
+			}
 			return
 		}
 
 		edges, exists := g(current)
-		if !exists {
+		if !exists || !m.visit(0) {
 			return
 		}
 
+		silent[current] = true
 		for _, edge := range edges {
-			dfs(edge.destination, len+1, path+string(edge.label))
+			path = append(path, edge)
+			if edge.epsilon {
+				dfs(edge.destination, length)
+			} else {
+				saved := silent
+				silent = map[node]bool{}
+				dfs(edge.destination, length+1)
+				silent = saved
+			}
+			path = path[:len(path)-1]
 		}
+		delete(silent, current)
 	}
-	dfs(s, 0, "")
-	return paths
+	dfs(s, 0)
+	return paths, m.err
 }
 
 /*
-	Generat all path in G1
-	- start from node s and find all path of len k that end at node t
-	- store each path as a string (eg "abc") in a slice for G1
-
-	Generate all path in G2
-	- similarly to generate path in G1, start from s to t with the len k
-	- store each path as string in a slice for g2
-	Compare paths from g1 and g2
-	- for each path in g1, if there is a path that exists in g2 -> false
-	- otherwise, return the path and true
-
-	build paths -> compare paths -> return result
+	Walk G1 and G2 together
+	- start from node s in G1 and the epsilon closure of s in G2
+	- for each edge in G1, split its labels by where they lead in G2, and
+	  move to the destination in G1 and the set of nodes reached in G2
+	- a node of G1 together with the set of G2 nodes and the length so far
+	  is visited at most once
+
+	Answer
+	- when the length is k, G1 is at t, and t is not in the set for G2,
+	  the labels read so far are a sequence of G1 that G2 does not have
+	- otherwise, return nil and false
 */
@@ -222,6 +222,15 @@ func TestFindSequence(t *testing.T) {
 			"test_1_17", g1, g2, 0, 6, 5,
 			true, []rune{'b', 'f', 'k', 'm', 'l'},
 		},
+		// lengths no sequence reaches, far beyond what fits in memory
+		{
+			"test_1_18", g1, g2, 2, 0, 1 << 62,
+			false, nil,
+		},
+		{
+			"test_1_19", g1, g2, 6, 6, 1<<64 - 1,
+			false, nil,
+		},
 	}
 
 	for _, test := range tests {
@@ -240,7 +249,7 @@ func TestFindSequence(t *testing.T) {
 					test.expectedSequence, test.expectedSequenceExists,
 					ans_sequence, ans_sequence_exists,
 				)
-				t.Errorf(message)
+				t.Error(message)
 			}
 		}()
 	}
@@ -295,7 +304,7 @@ func TestFindSequenceTwoSolutions(t *testing.T) {
 					test.expectedSequence2, test.expectedSequenceExists,
 					ans_sequence, ans_sequence_exists,
 				)
-				t.Errorf(message)
+				t.Error(message)
 			}
 		}()
 	}
@@ -351,7 +360,7 @@ func TestFindSequenceFiveSolutions(t *testing.T) {
 					test.expectedSequence5, test.expectedSequenceExists,
 					ans_sequence, ans_sequence_exists,
 				)
-				t.Errorf(message)
+				t.Error(message)
 			}
 		}()
 	}
@@ -419,8 +428,169 @@ func TestFindSequenceEightSolutions(t *testing.T) {
 					test.expectedSequence8, test.expectedSequenceExists,
 					ans_sequence, ans_sequence_exists,
 				)
-				t.Errorf(message)
+				t.Error(message)
 			}
 		}()
 	}
 }
+
+/*
+Graph G4:
+
+	0 --ε--> 1
+	1 --[a-z]--> 2
+	2 --ε--> 3
+	2 --ε--> 0
+	3 --x--> 4
+*/
+func g4(source node) ([]edge, bool) {
+	graph := map[node][]edge{
+		0: {
+			epsilonEdge(1),
+		},
+		1: {
+			setEdge(2, "[a-z]"),
+		},
+		2: {
+			epsilonEdge(3),
+			epsilonEdge(0),
+		},
+		3: {
+			{destination: 4, label: 'x'},
+		},
+		4: {},
+	}
+	edges, exists := graph[source]
+	return mkCopy(edges, exists)
+}
+
+/*
+Graph G5:
+
+	0 --[a-m]--> 2
+	2 --ε--> 0
+	0 --x--> 3
+	2 --x--> 4
+*/
+func g5(source node) ([]edge, bool) {
+	graph := map[node][]edge{
+		0: {
+			setEdge(2, "[a-m]"),
+			{destination: 3, label: 'x'},
+		},
+		2: {
+			epsilonEdge(0),
+			{destination: 4, label: 'x'},
+		},
+		3: {},
+		4: {},
+	}
+	edges, exists := graph[source]
+	return mkCopy(edges, exists)
+}
+
+func TestFindSequenceEpsilonAndSets(t *testing.T) {
+	tests := []struct {
+		testID                 string
+		graph1                 LGraph
+		graph2                 LGraph
+		source, target         node
+		sequenceLength         uint
+		expectedSequenceExists bool
+		expectedSequence       []rune
+	}{
+		{
+			"test_e_00", g4, g5, 0, 1, 0,
+			true, []rune{},
+		},
+		{
+			"test_e_01", g4, g5, 0, 2, 1,
+			true, []rune{'n'},
+		},
+		{
+			"test_e_02", g4, g5, 0, 3, 1,
+			true, []rune{'a'},
+		},
+		{
+			"test_e_03", g4, g5, 0, 4, 2,
+			true, []rune{'n', 'x'},
+		},
+		{
+			"test_e_04", g4, g4, 0, 4, 3,
+			false, nil,
+		},
+		{
+			"test_e_05", g5, g4, 0, 2, 2,
+			false, nil,
+		},
+		{
+			"test_e_06", g5, g4, 0, 3, 1,
+			false, nil,
+		},
+		{
+			"test_e_07", g4, g1, 0, 4, 2,
+			true, []rune{'a', 'x'},
+		},
+		{
+			"test_e_08", g1, g5, 0, 0, 1,
+			false, nil,
+		},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() != nil {
+					t.Errorf("FindSequence panicked on (%s, %d, %d, %d)",
+						test.testID, test.source, test.target, test.sequenceLength)
+				}
+			}()
+			ans_sequence, ans_sequence_exists := FindSequence(test.graph1, test.graph2, test.source, test.target, test.sequenceLength)
+			if ans_sequence_exists != test.expectedSequenceExists || !reflect.DeepEqual(ans_sequence, test.expectedSequence) {
+				t.Errorf("FindSequence failed on (%s, %d, %d, %d); expected: (%q, %t), got (%q, %t).",
+					test.testID, test.source, test.target, test.sequenceLength,
+					test.expectedSequence, test.expectedSequenceExists,
+					ans_sequence, ans_sequence_exists,
+				)
+			}
+		}()
+	}
+}
+
+func TestFindPaths(t *testing.T) {
+	tests := []struct {
+		graph          LGraph
+		source, target node
+		pathLength     uint
+		expected       []string
+	}{
+		{g1, 0, 6, 5, []string{"abfjl", "bfkml"}},
+		{g1, 0, 7, 4, []string{"abfj", "bfkm"}},
+		{g1, 17, 17, 0, []string{""}},
+		{g1, 17, 0, 1, nil},
+		{g4, 0, 4, 2, []string{"ε[a-z]εx"}},
+		{g4, 0, 1, 1, []string{"ε[a-z]εε"}},
+		{g2, 2, 2, 2, []string{"dd"}},
+	}
+	for i, test := range tests {
+		var actual []string
+		for _, path := range findPaths(test.graph, test.source, test.target, test.pathLength) {
+			str := ""
+			for _, edge := range path {
+				switch {
+				case edge.epsilon:
+					str += "ε"
+				case edge.labels != nil:
+					str += edge.labels.String()
+				default:
+					str += string(edge.label)
+				}
+			}
+			actual = append(actual, str)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("#%d: findPaths(%d, %d, %d)=%q; want %q", i,
+				test.source, test.target, test.pathLength, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package lgraph
+
+import (
+	"unicode/utf8"
+)
+
+// Lasso is an infinite trace made of the labels of Prefix followed by those of
+// Cycle repeated forever.
+type Lasso struct {
+	Prefix, Cycle []rune
+}
+
+// core rewrites p with true, labels, negation, conjunction, next and until
+// only. It returns ErrNotLTL if p has a path quantifier.
+func (p Property) core() (Property, error) {
+	args := make([]Property, len(p.args))
+	for i, arg := range p.args {
+		var err error
+		if args[i], err = arg.core(); err != nil {
+			return Property{}, err
+		}
+	}
+	switch p.op {
+	case propertyTrue, propertyLabel:
+		return p, nil
+	case propertyNot:
+		return negate(args[0]), nil
+	case propertyAnd:
+		return And(args[0], args[1]), nil
+	case propertyOr:
+		return negate(And(negate(args[0]), negate(args[1]))), nil
+	case propertyNext:
+		return Next(args[0]), nil
+	case propertyUntil:
+		return Until(args[0], args[1]), nil
+	case propertyRelease:
+		// p R q = !(!p U !q)
+		return negate(Until(negate(args[0]), negate(args[1]))), nil
+	case propertyEventually:
+		return Until(True(), args[0]), nil
+	case propertyAlways:
+		// G p = !(true U !p)
+		return negate(Until(True(), negate(args[0]))), nil
+	}
+	return Property{}, ErrNotLTL
+}
+
+// negate returns the negation of p, without double negations.
+func negate(p Property) Property {
+	if p.op == propertyNot {
+		return p.args[0]
+	}
+	return Not(p)
+}
+
+// tableauAtom is a maximal consistent set of subformulas of a property: the
+// subformulas that hold at a position of a trace, given as one truth value per
+// subformula. Allowed is the set of labels that can be read at the position.
+type tableauAtom struct {
+	value   []bool
+	allowed runeSet
+}
+
+// tableau is a generalized Büchi automaton accepting the traces that satisfy
+// a property in core form. Its states are the atoms of the property; a trace
+// goes from an atom to the next reading a label the atom allows.
+type tableau struct {
+	atoms   []tableauAtom
+	next    [][]int
+	initial []int
+	// accepting[j][a] is true iff atom a fulfils the j-th until, that is, the
+	// until does not hold there or its right-hand side does
+	accepting [][]bool
+}
+
+func newTableau(p Property) (*tableau, error) {
+	// list the subformulas, arguments first
+	var subs []Property
+	var args [][]int
+	index := map[string]int{}
+	var collect func(p Property) int
+	collect = func(p Property) int {
+		key := p.String()
+		if i, seen := index[key]; seen {
+			return i
+		}
+		var is []int
+		for _, arg := range p.args {
+			is = append(is, collect(arg))
+		}
+		index[key] = len(subs)
+		subs = append(subs, p)
+		args = append(args, is)
+		return len(subs) - 1
+	}
+	root := collect(p)
+
+	// labels, nexts and untils can hold or not independently, up to the
+	// consistency of the untils and of the labels read
+	var elementary []int
+	sets := make([]runeSet, len(subs))
+	for i, sub := range subs {
+		switch sub.op {
+		case propertyLabel:
+			set, err := parseRuneSet(sub.set)
+			if err != nil {
+				return nil, err
+			}
+			sets[i] = set
+			elementary = append(elementary, i)
+		case propertyNext, propertyUntil:
+			elementary = append(elementary, i)
+		}
+	}
+
+	t := &tableau{}
+	var untils []int
+	for i, sub := range subs {
+		if sub.op == propertyUntil {
+			untils = append(untils, i)
+		}
+	}
+	for bits := 0; bits < 1<<len(elementary); bits++ {
+		value := make([]bool, len(subs))
+		for j, i := range elementary {
+			value[i] = bits&(1<<j) != 0
+		}
+		allowed := runeSet{{0, utf8.MaxRune}}
+		consistent := true
+		for i, sub := range subs {
+			switch sub.op {
+			case propertyTrue:
+				value[i] = true
+			case propertyLabel:
+				if value[i] {
+					allowed = intersect(allowed, sets[i])
+				} else {
+					allowed = minus(allowed, sets[i])
+				}
+			case propertyNot:
+				value[i] = !value[args[i][0]]
+			case propertyAnd:
+				value[i] = value[args[i][0]] && value[args[i][1]]
+			case propertyUntil:
+				left, right := value[args[i][0]], value[args[i][1]]
+				if right && !value[i] || !left && !right && value[i] {
+					consistent = false
+				}
+			}
+		}
+		if consistent && len(allowed) > 0 {
+			t.atoms = append(t.atoms, tableauAtom{value, allowed})
+		}
+	}
+
+	for a, atom := range t.atoms {
+		if atom.value[root] {
+			t.initial = append(t.initial, a)
+		}
+		var next []int
+		for b, succ := range t.atoms {
+			ok := true
+			for i, sub := range subs {
+				switch sub.op {
+				case propertyNext:
+					ok = ok && atom.value[i] == succ.value[args[i][0]]
+				case propertyUntil:
+					// an until that holds only thanks to its left-hand side
+					// must hold at the next position too
+					if !atom.value[args[i][1]] && atom.value[args[i][0]] {
+						ok = ok && atom.value[i] == succ.value[i]
+					}
+				}
+			}
+			if ok {
+				next = append(next, b)
+			}
+		}
+		t.next = append(t.next, next)
+	}
+
+	for _, u := range untils {
+		fulfilled := make([]bool, len(t.atoms))
+		for a, atom := range t.atoms {
+			fulfilled[a] = !atom.value[u] || atom.value[args[u][1]]
+		}
+		t.accepting = append(t.accepting, fulfilled)
+	}
+	return t, nil
+}
+
+// FindCounterexample returns (L, true, nil) if some infinite trace from node s
+// in graph g does not satisfy p, where L is such a trace; else it returns
+// (Lasso{}, false, nil). It searches the product of g with a Büchi automaton
+// for the negation of p using a nested depth-first search. It returns
+// ErrNotLTL if p is not in LTL, or ErrRuneSet if a label of p is not a valid
+// rune set.
+func FindCounterexample(g LGraph, s node, p Property) (Lasso, bool, error) {
+	f, err := p.core()
+	if err != nil {
+		return Lasso{}, false, err
+	}
+	t, err := newTableau(negate(f))
+	if err != nil {
+		return Lasso{}, false, err
+	}
+	if _, exists := g(s); !exists {
+		return Lasso{}, false, nil
+	}
+
+	// the states of the product are a node of g, an atom and the index of the
+	// until whose fulfilment is awaited, which degeneralizes the automaton
+	type state struct {
+		current node
+		atom    int
+		until   int
+	}
+	type move struct {
+		next  state
+		label rune
+	}
+	successors := func(x state) []move {
+		until := x.until
+		if len(t.accepting) > 0 && t.accepting[x.until][x.atom] {
+			until = (until + 1) % len(t.accepting)
+		}
+		var result []move
+		for _, n := range closure(g, []node{x.current}) {
+			edges, _ := g(n)
+			for _, e := range edges {
+				if e.epsilon {
+					continue
+				}
+				labels := intersect(e.accepts(), t.atoms[x.atom].allowed)
+				if len(labels) == 0 {
+					continue
+				}
+				for _, b := range t.next[x.atom] {
+					result = append(result, move{state{e.destination, b, until}, labels[0].lo})
+				}
+			}
+		}
+		return result
+	}
+	accepting := func(x state) bool {
+		return len(t.accepting) == 0 || x.until == 0 && t.accepting[0][x.atom]
+	}
+
+	outerVisited := map[state]bool{}
+	innerVisited := map[state]bool{}
+	var prefix, cycle []rune
+
+	// inner looks for a way back to seed
+	var inner func(x, seed state) bool
+	inner = func(x, seed state) bool {
+		for _, m := range successors(x) {
+			if m.next == seed {
+				cycle = append(cycle, m.label)
+				return true
+			}
+			if innerVisited[m.next] {
+				continue
+			}
+			innerVisited[m.next] = true
+			cycle = append(cycle, m.label)
+			if inner(m.next, seed) {
+				return true
+			}
+			cycle = cycle[:len(cycle)-1]
+		}
+		return false
+	}
+	// outer starts an inner search from every accepting state once it is
+	// done with it
+	var outer func(x state) bool
+	outer = func(x state) bool {
+		outerVisited[x] = true
+		for _, m := range successors(x) {
+			if outerVisited[m.next] {
+				continue
+			}
+			prefix = append(prefix, m.label)
+			if outer(m.next) {
+				return true
+			}
+			prefix = prefix[:len(prefix)-1]
+		}
+		return accepting(x) && inner(x, x)
+	}
+
+	for _, a := range t.initial {
+		x := state{s, a, 0}
+		if !outerVisited[x] && outer(x) {
+			return Lasso{append([]rune{}, prefix...), cycle}, true, nil
+		}
+	}
+	return Lasso{}, false, nil
+}
+
+// CheckLTL returns true iff every infinite trace from node s in graph g
+// satisfies p, which makes p hold if g has no such trace. It returns ErrNotLTL
+// if p is not in LTL, or ErrRuneSet if a label of p is not a valid rune set.
+func CheckLTL(g LGraph, s node, p Property) (bool, error) {
+	_, found, err := FindCounterexample(g, s, p)
+	return !found && err == nil, err
+}
@@ -0,0 +1,221 @@
+package lgraph
+
+import (
+	"errors"
+	"testing"
+)
+
+// green, yellow, red, green, ...
+var gLight = mkGraph(map[node][]edge{
+	0: {{destination: 1, label: 'g'}},
+	1: {{destination: 2, label: 'y'}},
+	2: {{destination: 0, label: 'r'}},
+})
+
+// a* or a* b c^ω
+var gCommit = mkGraph(map[node][]edge{
+	0: {{destination: 0, label: 'a'}, {destination: 1, label: 'b'}},
+	1: {{destination: 1, label: 'c'}},
+})
+
+// a c^ω, since a b gets stuck
+var gStuck = mkGraph(map[node][]edge{
+	0: {{destination: 1, label: 'a'}},
+	1: {{destination: 2, label: 'b'}, {destination: 1, label: 'c'}},
+	2: {},
+})
+
+var gSetLoop = mkGraph(map[node][]edge{
+	0: {setEdge(0, "[a-c]")},
+})
+
+var gSilentLoop2 = mkGraph(map[node][]edge{
+	0: {epsilonEdge(1)},
+	1: {{destination: 0, label: 'a'}, epsilonEdge(0)},
+})
+
+var (
+	pa, pb, pc = Label("[a]"), Label("[b]"), Label("[c]")
+	pg, py, pr = Label("[g]"), Label("[y]"), Label("[r]")
+)
+
+// evalLasso returns true iff the trace of lasso satisfies the LTL property p.
+func evalLasso(p Property, lasso Lasso) bool {
+	word := append(append([]rune{}, lasso.Prefix...), lasso.Cycle...)
+	succ := func(i int) int {
+		if i+1 < len(word) {
+			return i + 1
+		}
+		return len(lasso.Prefix)
+	}
+	// fixpoint iterates r[i] = f(i, r) from start until it is stable
+	fixpoint := func(start bool, f func(int, []bool) bool) []bool {
+		r := make([]bool, len(word))
+		for i := range r {
+			r[i] = start
+		}
+		for range word {
+			for i := range r {
+				r[i] = f(i, r)
+			}
+		}
+		return r
+	}
+	var eval func(p Property) []bool
+	eval = func(p Property) []bool {
+		var args [][]bool
+		for _, arg := range p.args {
+			args = append(args, eval(arg))
+		}
+		switch p.op {
+		case propertyTrue:
+			return fixpoint(true, func(int, []bool) bool { return true })
+		case propertyLabel:
+			set := mustRuneSet(p.set)
+			return fixpoint(true, func(i int, _ []bool) bool { return set.contains(word[i]) })
+		case propertyNot:
+			return fixpoint(true, func(i int, _ []bool) bool { return !args[0][i] })
+		case propertyAnd:
+			return fixpoint(true, func(i int, _ []bool) bool { return args[0][i] && args[1][i] })
+		case propertyOr:
+			return fixpoint(true, func(i int, _ []bool) bool { return args[0][i] || args[1][i] })
+		case propertyNext:
+			return fixpoint(true, func(i int, _ []bool) bool { return args[0][succ(i)] })
+		case propertyUntil:
+			return fixpoint(false, func(i int, r []bool) bool { return args[1][i] || args[0][i] && r[succ(i)] })
+		case propertyRelease:
+			return fixpoint(true, func(i int, r []bool) bool { return args[1][i] && (args[0][i] || r[succ(i)]) })
+		case propertyEventually:
+			return fixpoint(false, func(i int, r []bool) bool { return args[0][i] || r[succ(i)] })
+		default:
+			return fixpoint(true, func(i int, r []bool) bool { return args[0][i] && r[succ(i)] })
+		}
+	}
+	return eval(p)[0]
+}
+
+// isTrace returns true iff g has a path from s reading the prefix of lasso
+// and then its cycle any number of times.
+func isTrace(g LGraph, s node, lasso Lasso) bool {
+	if len(lasso.Cycle) == 0 {
+		return false
+	}
+	current := closure(g, []node{s})
+	read := func(sequence []rune) {
+		for _, r := range sequence {
+			var next []node
+			for _, n := range current {
+				edges, _ := g(n)
+				for _, e := range edges {
+					if e.accepts().contains(r) {
+						next = append(next, e.destination)
+					}
+				}
+			}
+			current = closure(g, next)
+		}
+	}
+	read(lasso.Prefix)
+	// the sets reached after every turn of the cycle repeat eventually
+	seen := map[string]bool{}
+	for len(current) > 0 && !seen[nodeSetKey(current)] {
+		seen[nodeSetKey(current)] = true
+		read(lasso.Cycle)
+	}
+	return len(current) > 0
+}
+
+func TestCheckLTL(t *testing.T) {
+	tests := []struct {
+		graph    LGraph
+		source   node
+		property Property
+		holds    bool
+	}{
+		{gLight, 0, Always(Implies(pg, Next(py))), true},
+		{gLight, 0, Eventually(pr), true},
+		{gLight, 0, Always(Eventually(pr)), true},
+		{gLight, 0, Always(Not(pr)), false},
+		{gLight, 0, Next(Next(pr)), true},
+		{gLight, 1, Next(Next(pr)), false},
+		{gLight, 0, Until(pg, py), true},
+		{gLight, 0, Eventually(Always(pg)), false},
+		{gLight, 0, Release(pr, Not(pr)), false},
+		{gLight, 0, Release(py, Not(pr)), true},
+		{gCommit, 0, Eventually(pb), false},
+		{gCommit, 0, Always(Implies(pb, Next(Always(pc)))), true},
+		{gCommit, 0, Always(Not(pb)), false},
+		{gCommit, 0, Until(pa, pb), false},
+		{gCommit, 0, Or(Always(pa), Until(pa, And(pb, Next(Always(pc))))), true},
+		{gCommit, 0, Eventually(Always(Or(pa, pc))), true},
+		{gCommit, 1, Always(pc), true},
+		{gStuck, 0, Always(Implies(pa, Next(pc))), true},
+		{gStuck, 0, Always(Not(pb)), true},
+		{gStuck, 0, Eventually(pb), false},
+		{gSetLoop, 0, Always(Label("[a-c]")), true},
+		{gSetLoop, 0, Always(pa), false},
+		{gSetLoop, 0, Eventually(pc), false},
+		{gSetLoop, 0, Always(Eventually(pb)), false},
+		{gSilentLoop2, 0, Always(pa), true},
+		{gSilentLoop2, 0, Eventually(pb), false},
+		{gLight, 7, Always(pa), true},
+		{g2, 0, Always(Eventually(Label("[a-c]"))), false},
+		{g2, 0, Eventually(Label("[a-f]")), true},
+	}
+	for i, test := range tests {
+		holds, err := CheckLTL(test.graph, test.source, test.property)
+		if err != nil || holds != test.holds {
+			t.Errorf("#%d: CheckLTL(%d, %v)=(%t, %v); want (%t, nil)", i, test.source, test.property, holds, err, test.holds)
+		}
+		lasso, found, err := FindCounterexample(test.graph, test.source, test.property)
+		if err != nil || found == test.holds {
+			t.Errorf("#%d: FindCounterexample(%d, %v)=(%v, %t, %v); want (_, %t, nil)",
+				i, test.source, test.property, lasso, found, err, !test.holds)
+			continue
+		}
+		if found && (!isTrace(test.graph, test.source, lasso) || evalLasso(test.property, lasso)) {
+			t.Errorf("#%d: FindCounterexample(%d, %v) gives %q(%q)^ω, which is not a counterexample",
+				i, test.source, test.property, lasso.Prefix, lasso.Cycle)
+		}
+	}
+}
+
+func TestFindCounterexample(t *testing.T) {
+	lasso, found, _ := FindCounterexample(gCommit, 0, Always(Not(pb)))
+	if !found || string(lasso.Prefix) != "ab" || string(lasso.Cycle) != "c" {
+		t.Errorf("FindCounterexample(G!b)=(%q(%q)^ω, %t); want (\"ab\"(\"c\")^ω, true)", lasso.Prefix, lasso.Cycle, found)
+	}
+}
+
+func TestCheckLTLErrors(t *testing.T) {
+	tests := []struct {
+		property Property
+		err      error
+	}{
+		{Exists(Eventually(pa)), ErrNotLTL},
+		{Always(ForAll(Next(pa))), ErrNotLTL},
+		{Always(Label("a")), ErrRuneSet},
+		{Eventually(Label("[b-a]")), ErrRuneSet},
+	}
+	for i, test := range tests {
+		if _, err := CheckLTL(gLight, 0, test.property); !errors.Is(err, test.err) {
+			t.Errorf("#%d: CheckLTL(%v) gives error %v; want %v", i, test.property, err, test.err)
+		}
+	}
+}
+
+func TestPropertyString(t *testing.T) {
+	tests := []struct {
+		property Property
+		expected string
+	}{
+		{ForAll(Always(Implies(pa, Eventually(pb)))), "AG(![a] | F[b])"},
+		{Exists(Until(True(), And(pa, Next(pc)))), "E(true U ([a] & X[c]))"},
+		{Release(Label("[a-z]"), pb), "([a-z] R [b])"},
+	}
+	for i, test := range tests {
+		if str := test.property.String(); str != test.expected {
+			t.Errorf("#%d: String()=%q; want %q", i, str, test.expected)
+		}
+	}
+}
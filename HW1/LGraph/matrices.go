@@ -0,0 +1,138 @@
+package lgraph
+
+import "math/big"
+
+// The matrices below are plain [][]int so that package matrix, which this
+// package does not import, can take their powers: entry (i, j) of the k-th
+// power of AdjacencyMatrix counts the walks of k edges from the i-th to the
+// j-th node, and the product of the LabelMatrices of the runes of a word
+// counts the walks that read the word.
+
+// nodeIndex returns the index of every node of nodes.
+func nodeIndex(nodes []node) map[node]int {
+	index := make(map[node]int, len(nodes))
+	for i, n := range nodes {
+		index[n] = i
+	}
+	return index
+}
+
+// AdjacencyMatrix returns the nodes reachable from s in g, sorted as by
+// Reachable, and the matrix whose entry (i, j) is the number of labeled edges
+// from the i-th to the j-th of them. An edge labeled by a set of runes counts
+// once. Epsilon edges are left out, so the walks the powers count are those
+// that take no epsilon edge.
+func AdjacencyMatrix(g LGraph, s node) ([][]int, []node) {
+	return transferMatrix(g, s, func(e edge) int {
+		if e.epsilon {
+			return 0
+		}
+		return 1
+	})
+}
+
+// LabelMatrices returns the nodes reachable from s in g, sorted as by
+// Reachable, and for every rune of alphabet the transfer matrix of the rune:
+// the matrix whose entry (i, j) is the number of edges from the i-th to the
+// j-th node that accept the rune. Epsilon edges are left out.
+func LabelMatrices(g LGraph, s node, alphabet string) (map[rune][][]int, []node) {
+	matrices := map[rune][][]int{}
+	var nodes []node
+	for _, r := range alphabet {
+		if _, done := matrices[r]; done {
+			continue
+		}
+		matrices[r], nodes = transferMatrix(g, s, func(e edge) int {
+			if e.accepts().contains(r) {
+				return 1
+			}
+			return 0
+		})
+	}
+	if nodes == nil {
+		nodes = Reachable(g, s)
+	}
+	return matrices, nodes
+}
+
+// transferMatrix returns the nodes reachable from s in g and the matrix whose
+// entry (i, j) is the sum of count(e) over the edges e from the i-th to the
+// j-th of them.
+func transferMatrix(g LGraph, s node, count func(edge) int) ([][]int, []node) {
+	nodes := Reachable(g, s)
+	index := nodeIndex(nodes)
+	mat := make([][]int, len(nodes))
+	for i, n := range nodes {
+		mat[i] = make([]int, len(nodes))
+		edges, _ := g(n)
+		for _, e := range edges {
+			mat[i][index[e.destination]] += count(e)
+		}
+	}
+	return mat, nodes
+}
+
+// CountWalks returns the number of walks of k labeled edges from s to t in g,
+// the entry for s and t of the k-th power of AdjacencyMatrix. An edge labeled
+// by a set of runes counts once and epsilon edges are not taken, unlike in
+// CountSequences. The power takes O(log k) products of n×n matrices for the n
+// nodes reachable from s.
+func CountWalks(g LGraph, s, t node, k uint) *big.Int {
+	mat, nodes := AdjacencyMatrix(g, s)
+	index := nodeIndex(nodes)
+	i, found := index[s]
+	j, reached := index[t]
+	if !found || !reached {
+		return new(big.Int)
+	}
+	return powerBig(mat, k)[i][j]
+}
+
+// powerBig returns the k-th power of the square matrix mat by repeated
+// squaring.
+//
+// powerBig and multiplyBig duplicate matrix.PowerBig and matrix.MultiplyBig on
+// purpose: HW1 has no module, so lgraph cannot import package matrix. A change
+// to either copy must be made to the other as well; TestCountWalks checks this
+// one against walks counted one by one.
+func powerBig(mat [][]int, k uint) [][]*big.Int {
+	result, square := make([][]*big.Int, len(mat)), make([][]*big.Int, len(mat))
+	for i, row := range mat {
+		result[i], square[i] = make([]*big.Int, len(mat)), make([]*big.Int, len(mat))
+		for j, n := range row {
+			result[i][j], square[i][j] = new(big.Int), big.NewInt(int64(n))
+		}
+		result[i][i].SetInt64(1)
+	}
+	for ; k > 0; k >>= 1 {
+		if k&1 == 1 {
+			result = multiplyBig(result, square)
+		}
+		if k > 1 {
+			square = multiplyBig(square, square)
+		}
+	}
+	return result
+}
+
+// multiplyBig returns the product of the square matrices a and b of the same
+// size, as matrix.MultiplyBig does.
+func multiplyBig(a, b [][]*big.Int) [][]*big.Int {
+	product := make([][]*big.Int, len(a))
+	term := new(big.Int)
+	for i := range product {
+		product[i] = make([]*big.Int, len(a))
+		for j := range product[i] {
+			product[i][j] = new(big.Int)
+		}
+		for k, aik := range a[i] {
+			if aik.Sign() == 0 {
+				continue
+			}
+			for j, bkj := range b[k] {
+				product[i][j].Add(product[i][j], term.Mul(aik, bkj))
+			}
+		}
+	}
+	return product
+}
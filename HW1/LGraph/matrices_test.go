@@ -0,0 +1,128 @@
+package lgraph
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// walks counts the walks of k labeled edges from s to t in g one by one, for
+// checking CountWalks.
+func walks(g LGraph, s, t node, k uint) int64 {
+	if k == 0 {
+		if s == t {
+			return 1
+		}
+		return 0
+	}
+	edges, _ := g(s)
+	count := int64(0)
+	for _, e := range edges {
+		if !e.epsilon {
+			count += walks(g, e.destination, t, k-1)
+		}
+	}
+	return count
+}
+
+func TestAdjacencyMatrix(t *testing.T) {
+	tests := []struct {
+		g        LGraph
+		s        node
+		expected [][]int
+		nodes    []node
+	}{
+		{gLoop, 0, [][]int{{1}}, []node{0}},
+		{gLoop2, 1, [][]int{{0, 1}, {1, 0}}, []node{0, 1}},
+		{gRunes, 0, [][]int{{0, 2, 1}, {0, 0, 0}, {0, 0, 0}}, []node{0, 1, 2}},
+		{gRange, 0, [][]int{{0, 1}, {0, 0}}, []node{0, 1}},
+		{gSilent, 0, [][]int{{0, 0, 0}, {0, 0, 1}, {0, 0, 0}}, []node{0, 1, 2}},
+		{gChoiceLate, 1, [][]int{{0, 1, 1}, {0, 0, 0}, {0, 0, 0}}, []node{1, 2, 3}},
+		{gLoop, 5, [][]int{}, nil},
+	}
+	for i, test := range tests {
+		mat, nodes := AdjacencyMatrix(test.g, test.s)
+		if !reflect.DeepEqual(mat, test.expected) || !reflect.DeepEqual(nodes, test.nodes) {
+			t.Errorf("#%d: AdjacencyMatrix(g, %d)=%v, %v; want %v, %v", i,
+				test.s, mat, nodes, test.expected, test.nodes)
+		}
+	}
+}
+
+// In the complete graph on n nodes there are n^(k-1) walks of k edges between
+// any two nodes.
+func TestCountWalks(t *testing.T) {
+	want := big.NewInt(1)
+	for k := uint(1); k <= 40; k++ {
+		if k > 1 {
+			want.Mul(want, big.NewInt(4))
+		}
+		if actual := CountWalks(complete(4), 0, 3, k); actual.Cmp(want) != 0 {
+			t.Errorf("CountWalks(complete(4), 0, 3, %d)=%v; want %v", k, actual, want)
+		}
+	}
+
+	tests := []struct {
+		g    LGraph
+		s, t node
+	}{
+		{gLoop, 0, 0},
+		{gLoop2, 1, 0},
+		{gRunes, 0, 1},
+		{gSilent, 0, 2},
+		{gSilent, 1, 2},
+		{gChoiceLate, 1, 3},
+		{complete(3), 2, 1},
+		{gLoop, 5, 0},
+		{gLoop2, 0, 7},
+	}
+	for i, test := range tests {
+		for k := uint(0); k <= 6; k++ {
+			want := walks(test.g, test.s, test.t, k)
+			if _, exists := test.g(test.s); !exists {
+				want = 0
+			}
+			if actual := CountWalks(test.g, test.s, test.t, k); actual.Int64() != want {
+				t.Errorf("#%d: CountWalks(g, %d, %d, %d)=%v; want %d", i, test.s, test.t, k, actual, want)
+			}
+		}
+	}
+}
+
+func TestLabelMatrices(t *testing.T) {
+	matrices, nodes := LabelMatrices(gRunes, 0, "abcda")
+	expected := map[rune][][]int{
+		'a': {{0, 1, 0}, {0, 0, 0}, {0, 0, 0}},
+		'b': {{0, 1, 0}, {0, 0, 0}, {0, 0, 0}},
+		'c': {{0, 0, 1}, {0, 0, 0}, {0, 0, 0}},
+		'd': {{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+	}
+	if !reflect.DeepEqual(matrices, expected) || !reflect.DeepEqual(nodes, []node{0, 1, 2}) {
+		t.Errorf("LabelMatrices(gRunes, 0, %q)=%v, %v; want %v, [0 1 2]", "abcda", matrices, nodes, expected)
+	}
+
+	matrices, _ = LabelMatrices(gRange, 0, "bz")
+	if matrices['b'][0][1] != 1 || matrices['z'][0][1] != 0 {
+		t.Errorf("LabelMatrices(gRange, 0, %q)=%v; want b to lead from 0 to 1 and z not", "bz", matrices)
+	}
+	if _, nodes := LabelMatrices(gLoop2, 0, ""); !reflect.DeepEqual(nodes, []node{0, 1}) {
+		t.Errorf("LabelMatrices(gLoop2, 0, \"\") has nodes %v; want [0 1]", nodes)
+	}
+}
+
+// In a graph without epsilon edges where every node has at most one edge per
+// rune, the walks are the sequences CountSequences counts.
+func TestCountWalksCountSequences(t *testing.T) {
+	g := mkGraph(map[node][]edge{
+		0: {{destination: 1, label: 'a'}, {destination: 0, label: 'b'}},
+		1: {{destination: 0, label: 'a'}, {destination: 2, label: 'b'}, {destination: 1, label: 'c'}},
+		2: {{destination: 0, label: 'c'}},
+	})
+	for k := uint(0); k <= 8; k++ {
+		for _, n := range []node{0, 1, 2} {
+			if want, actual := CountSequences(g, 0, n, k), CountWalks(g, 0, n, k); actual.Cmp(want) != 0 {
+				t.Errorf("CountWalks(g, 0, %d, %d)=%v; CountSequences gives %v", n, k, actual, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package lgraph
+
+import (
+	"strconv"
+	"sync"
+)
+
+// Automaton is a graph with a start node and a final node. It accepts the
+// sequences from Start to Final in Graph.
+type Automaton struct {
+	Graph        LGraph
+	Start, Final node
+}
+
+// Accepts returns true iff a accepts sequence.
+func (a Automaton) Accepts(sequence []rune) bool {
+	if _, exists := a.Graph(a.Start); !exists {
+		return false
+	}
+	current := closure(a.Graph, []node{a.Start})
+	for _, r := range sequence {
+		var next []node
+		for _, n := range current {
+			edges, _ := a.Graph(n)
+			for _, edge := range edges {
+				if edge.accepts().contains(r) {
+					next = append(next, edge.destination)
+				}
+			}
+		}
+		if current = closure(a.Graph, next); len(current) == 0 {
+			return false
+		}
+	}
+	return containsNode(current, a.Final)
+}
+
+// IsEmpty returns true iff a accepts no sequence at all.
+func (a Automaton) IsEmpty() bool {
+	return !containsNode(Reachable(a.Graph, a.Start), a.Final)
+}
+
+// lazyGraph is a graph whose nodes stand for states of type S and are numbered
+// as they are discovered. The edges of a node are computed by expand the
+// first time they are asked for, and kept. A lazyGraph is safe for concurrent
+// use.
+type lazyGraph[S any] struct {
+	mu     sync.Mutex
+	key    func(S) string
+	expand func(state S, id func(S) node) []edge
+	ids    map[string]node
+	states []S
+	edges  map[node][]edge
+}
+
+func newLazyGraph[S any](key func(S) string, expand func(S, func(S) node) []edge) *lazyGraph[S] {
+	return &lazyGraph[S]{key: key, expand: expand, ids: map[string]node{}, edges: map[node][]edge{}}
+}
+
+// id returns the node standing for state, numbering it if it is new. The
+// caller must hold l.mu.
+func (l *lazyGraph[S]) id(state S) node {
+	key := l.key(state)
+	if n, seen := l.ids[key]; seen {
+		return n
+	}
+	n := node(len(l.states))
+	l.ids[key] = n
+	l.states = append(l.states, state)
+	return n
+}
+
+// node is like id for callers that do not hold l.mu.
+func (l *lazyGraph[S]) node(state S) node {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.id(state)
+}
+
+// graph is the LGraph of l.
+func (l *lazyGraph[S]) graph(n node) ([]edge, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if int(n) >= len(l.states) {
+		return nil, false
+	}
+	edges, seen := l.edges[n]
+	if !seen {
+		edges = l.expand(l.states[n], l.id)
+		l.edges[n] = edges
+	}
+	return mkEdgesCopy(edges), true
+}
+
+func mkEdgesCopy(edges []edge) []edge {
+	edgescopy := make([]edge, len(edges))
+	copy(edgescopy, edges)
+	return edgescopy
+}
+
+// intersect returns the runes in both a and b.
+func intersect(a, b runeSet) runeSet {
+	var result runeSet
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		lo, hi := max(a[i].lo, b[j].lo), min(a[i].hi, b[j].hi)
+		if lo <= hi {
+			result = append(result, runeRange{lo, hi})
+		}
+		if a[i].hi < b[j].hi {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
+
+// labeled returns an edge to destination labeled by the runes of labels.
+func labeled(destination node, labels runeSet) edge {
+	if len(labels) == 1 && labels[0].lo == labels[0].hi {
+		return edge{destination: destination, label: labels[0].lo}
+	}
+	return edge{destination: destination, labels: labels}
+}
+
+// Intersection returns the synchronous product of a and b, which accepts the
+// sequences that both a and b accept. Either side may take an epsilon edge on
+// its own; labeled edges are taken together, reading a rune both accept. The
+// weight of a labeled edge of the product is the sum of the weights of the
+// two edges.
+func Intersection(a, b Automaton) Automaton {
+	type pair struct {
+		p, q node
+	}
+	l := newLazyGraph(
+		func(s pair) string {
+			return strconv.FormatUint(uint64(s.p), 10) + "," + strconv.FormatUint(uint64(s.q), 10)
+		},
+		func(s pair, id func(pair) node) []edge {
+			var result []edge
+			pEdges, _ := a.Graph(s.p)
+			qEdges, _ := b.Graph(s.q)
+			for _, e := range pEdges {
+				if e.epsilon {
+					result = append(result, edge{destination: id(pair{e.destination, s.q}), epsilon: true, weight: e.weight})
+				}
+			}
+			for _, e := range qEdges {
+				if e.epsilon {
+					result = append(result, edge{destination: id(pair{s.p, e.destination}), epsilon: true, weight: e.weight})
+				}
+			}
+			for _, e1 := range pEdges {
+				for _, e2 := range qEdges {
+					if e1.epsilon || e2.epsilon {
+						continue
+					}
+					if both := intersect(e1.accepts(), e2.accepts()); len(both) > 0 {
+						e := labeled(id(pair{e1.destination, e2.destination}), both)
+						e.weight = e1.weight + e2.weight
+						result = append(result, e)
+					}
+				}
+			}
+			return result
+		},
+	)
+	start := l.node(pair{a.Start, b.Start})
+	final := l.node(pair{a.Final, b.Final})
+	graph := l.graph
+	if _, exists := a.Graph(a.Start); !exists {
+		graph = emptyGraph
+	} else if _, exists := b.Graph(b.Start); !exists {
+		graph = emptyGraph
+	}
+	return Automaton{graph, start, final}
+}
+
+// emptyGraph is the graph without nodes.
+func emptyGraph(node) ([]edge, bool) {
+	return nil, false
+}
+
+// Node numbering of Union and Concatenation: the nodes of the first automaton
+// are numbered 2n+offset and those of the second 2n+offset+1, where n is the
+// node in the original graph.
+func split(n, offset node) (node, bool) {
+	return (n - offset) / 2, (n-offset)%2 == 1
+}
+
+// Union returns the disjoint union of a and b, which accepts the sequences
+// that a or b accepts. Node 0 is the new start node and node 1 the new final
+// node; the node n of a becomes 2n+2 and the node n of b becomes 2n+3.
+func Union(a, b Automaton) Automaton {
+	graph := func(n node) ([]edge, bool) {
+		switch n {
+		case 0:
+			var edges []edge
+			for i, x := range []Automaton{a, b} {
+				if _, exists := x.Graph(x.Start); exists {
+					edges = append(edges, epsilonEdge(2*x.Start+2+node(i)))
+				}
+			}
+			return edges, true
+		case 1:
+			return []edge{}, true
+		}
+		original, second := split(n, 2)
+		x, offset := a, node(2)
+		if second {
+			x, offset = b, 3
+		}
+		edges, exists := x.Graph(original)
+		if !exists {
+			return nil, false
+		}
+		result := make([]edge, len(edges), len(edges)+1)
+		for i, e := range edges {
+			e.destination = 2*e.destination + offset
+			result[i] = e
+		}
+		if original == x.Final {
+			result = append(result, epsilonEdge(1))
+		}
+		return result, true
+	}
+	return Automaton{graph, 0, 1}
+}
+
+// Concatenation returns the automaton that accepts a sequence of a followed by
+// a sequence of b. The node n of a becomes 2n and the node n of b becomes
+// 2n+1, and the final node of a gets an epsilon edge to the start node of b.
+func Concatenation(a, b Automaton) Automaton {
+	graph := func(n node) ([]edge, bool) {
+		original, second := split(n, 0)
+		x, offset := a, node(0)
+		if second {
+			x, offset = b, 1
+		}
+		edges, exists := x.Graph(original)
+		if !exists {
+			return nil, false
+		}
+		result := make([]edge, len(edges), len(edges)+1)
+		for i, e := range edges {
+			e.destination = 2*e.destination + offset
+			result[i] = e
+		}
+		if !second && original == a.Final {
+			if _, exists := b.Graph(b.Start); exists {
+				result = append(result, epsilonEdge(2*b.Start+1))
+			}
+		}
+		return result, true
+	}
+	return Automaton{graph, 2 * a.Start, 2*b.Final + 1}
+}
+
+// Star returns the Kleene star of a, which accepts any number of sequences of
+// a one after the other, including none. Node 0 is the new start and final
+// node, and the node n of a becomes n+1.
+func Star(a Automaton) Automaton {
+	graph := func(n node) ([]edge, bool) {
+		if n == 0 {
+			if _, exists := a.Graph(a.Start); !exists {
+				return []edge{}, true
+			}
+			return []edge{epsilonEdge(a.Start + 1)}, true
+		}
+		edges, exists := a.Graph(n - 1)
+		if !exists {
+			return nil, false
+		}
+		result := make([]edge, len(edges), len(edges)+1)
+		for i, e := range edges {
+			e.destination++
+			result[i] = e
+		}
+		if n-1 == a.Final {
+			result = append(result, epsilonEdge(0))
+		}
+		return result, true
+	}
+	return Automaton{graph, 0, 0}
+}
+
+// determinize returns the subset construction of a over the runes of
+// alphabet. Every node of the result stands for the epsilon-closed set of
+// nodes of a that a sequence reaches, and has one edge per set of runes of
+// alphabet leading to the same set, including a sink for the empty set.
+// Node 0 is the start node and node 1 the final node, which the nodes whose
+// set satisfies final reach by an epsilon edge.
+func determinize(a Automaton, alphabet runeSet, final func([]node) bool) Automaton {
+	type state struct {
+		nodes []node
+		final bool // the extra final node
+	}
+	l := newLazyGraph(
+		func(s state) string {
+			if s.final {
+				return "final"
+			}
+			return "{" + nodeSetKey(s.nodes) + "}"
+		},
+		func(s state, id func(state) node) []edge {
+			if s.final {
+				return []edge{}
+			}
+			var result []edge
+			for _, class := range labelClasses(a.Graph, s.nodes, alphabet) {
+				result = append(result, labeled(id(state{nodes: class.next}), class.runes))
+			}
+			if final(s.nodes) {
+				result = append(result, epsilonEdge(id(state{final: true})))
+			}
+			return result
+		},
+	)
+	var start []node
+	if _, exists := a.Graph(a.Start); exists {
+		start = closure(a.Graph, []node{a.Start})
+	}
+	return Automaton{l.graph, l.node(state{nodes: start}), l.node(state{final: true})}
+}
+
+// Determinize returns an automaton accepting the same sequences over the
+// runes of alphabet as a, whose labeled edges leaving any node accept
+// disjoint sets of runes. The alphabet is a rune set such as "[a-z]".
+func Determinize(a Automaton, alphabet string) (Automaton, error) {
+	runes, err := parseRuneSet(alphabet)
+	if err != nil {
+		return Automaton{}, err
+	}
+	return determinize(a, runes, func(nodes []node) bool {
+		return containsNode(nodes, a.Final)
+	}), nil
+}
+
+// Complement returns the automaton that accepts exactly the sequences of runes
+// of alphabet that a does not accept. The alphabet is a rune set such as
+// "[a-z]".
+func Complement(a Automaton, alphabet string) (Automaton, error) {
+	runes, err := parseRuneSet(alphabet)
+	if err != nil {
+		return Automaton{}, err
+	}
+	return determinize(a, runes, func(nodes []node) bool {
+		return !containsNode(nodes, a.Final)
+	}), nil
+}
@@ -0,0 +1,132 @@
+package lgraph
+
+import (
+	"testing"
+)
+
+func mustComplement(a Automaton, alphabet string) Automaton {
+	c, err := Complement(a, alphabet)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func mustDeterminize(a Automaton, alphabet string) Automaton {
+	d, err := Determinize(a, alphabet)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestOperations(t *testing.T) {
+	late := Automaton{gChoiceLate, 0, 2}   // ab
+	late3 := Automaton{gChoiceLate, 0, 3}  // ac
+	early := Automaton{gChoiceEarly, 0, 3} // ab
+	rng := Automaton{gRange, 0, 1}         // a, b or c
+	loop := Automaton{gLoop, 0, 0}         // a*
+	loop2 := Automaton{gLoop2, 0, 0}       // (aa)*
+	silent := Automaton{gSilent, 0, 2}     // a
+	missing := Automaton{gRange, 5, 1}     // nothing
+
+	tests := []struct {
+		name       string
+		automaton  Automaton
+		accepted   []string
+		rejected   []string
+		expectedIs bool // IsEmpty
+	}{
+		{"late", late, []string{"ab"}, []string{"", "a", "ac", "abb"}, false},
+		{"missing", missing, nil, []string{"", "a"}, true},
+		{"late ∩ early", Intersection(late, early), []string{"ab"}, []string{"ac", ""}, false},
+		{"late3 ∩ early", Intersection(late3, early), nil, []string{"ab", "ac"}, true},
+		{"loop ∩ loop2", Intersection(loop, loop2), []string{"", "aa", "aaaa"}, []string{"a", "aaa"}, false},
+		{"rng ∩ silent", Intersection(rng, silent), []string{"a"}, []string{"b", ""}, false},
+		{"rng ∩ missing", Intersection(rng, missing), nil, []string{"a", ""}, true},
+		{"late ∪ rng", Union(late, rng), []string{"ab", "b", "c"}, []string{"ac", "", "abc"}, false},
+		{"missing ∪ silent", Union(missing, silent), []string{"a"}, []string{""}, false},
+		{"rng . late3", Concatenation(rng, late3), []string{"bac", "aac"}, []string{"ac", "ba", "bab"}, false},
+		{"silent . silent", Concatenation(silent, silent), []string{"aa"}, []string{"a", "aaa"}, false},
+		{"missing . rng", Concatenation(missing, rng), nil, []string{"a", ""}, true},
+		{"rng*", Star(rng), []string{"", "a", "abc", "cccc"}, []string{"d", "abd"}, false},
+		{"late*", Star(late), []string{"", "ab", "abab"}, []string{"a", "aba", "ba"}, false},
+		{"missing*", Star(missing), []string{""}, []string{"a"}, false},
+		{"¬late", mustComplement(late, "[a-c]"), []string{"", "a", "ac", "abb", "cc"}, []string{"ab", "ad", "d"}, false},
+		{"¬loop", mustComplement(loop, "[ab]"), []string{"b", "ab", "aab"}, []string{"", "aaa", "c"}, false},
+		{"¬missing", mustComplement(missing, "[ab]"), []string{"", "ab"}, []string{"c"}, false},
+		{"¬rng*", mustComplement(Star(Automaton{gRange, 0, 1}), "[a-c]"), nil, []string{"", "abc"}, true},
+		{"det early", mustDeterminize(early, "[a-z]"), []string{"ab"}, []string{"ac", "a", ""}, false},
+		{"det late*", mustDeterminize(Star(late), "[a-c]"), []string{"", "abab"}, []string{"a", "aba"}, false},
+	}
+	for _, test := range tests {
+		for _, sequence := range test.accepted {
+			if !test.automaton.Accepts([]rune(sequence)) {
+				t.Errorf("%s: Accepts(%q)=false; want true", test.name, sequence)
+			}
+		}
+		for _, sequence := range test.rejected {
+			if test.automaton.Accepts([]rune(sequence)) {
+				t.Errorf("%s: Accepts(%q)=true; want false", test.name, sequence)
+			}
+		}
+		if empty := test.automaton.IsEmpty(); empty != test.expectedIs {
+			t.Errorf("%s: IsEmpty()=%t; want %t", test.name, empty, test.expectedIs)
+		}
+	}
+}
+
+func TestOperationsBadAlphabet(t *testing.T) {
+	a := Automaton{gRange, 0, 1}
+	if _, err := Complement(a, "[a-"); err == nil {
+		t.Errorf("Complement(%q) gives no error", "[a-")
+	}
+	if _, err := Determinize(a, "a-z"); err == nil {
+		t.Errorf("Determinize(%q) gives no error", "a-z")
+	}
+}
+
+// The labeled edges leaving any node of a determinized automaton accept
+// disjoint sets of runes.
+func TestDeterminizeIsDeterministic(t *testing.T) {
+	d := mustDeterminize(Automaton{g2, 0, 6}, "[a-z]")
+	for _, n := range Reachable(d.Graph, d.Start) {
+		edges, _ := d.Graph(n)
+		var seen runeSet
+		for _, edge := range edges {
+			if edge.epsilon {
+				continue
+			}
+			if len(intersect(seen, edge.accepts())) > 0 {
+				t.Errorf("node %d: edges overlap on %s", n, intersect(seen, edge.accepts()))
+			}
+			seen = union(seen, edge.accepts())
+		}
+	}
+}
+
+// FindSequence finds a sequence of length k iff g1 ∩ ¬g2 accepts one.
+func TestIntersectionComplementAgreesWithFindSequence(t *testing.T) {
+	graphs := []LGraph{g1, g2, g3}
+	for i, graph1 := range graphs {
+		for j, graph2 := range graphs {
+			for s := node(0); s < 8; s++ {
+				for target := node(0); target < 8; target++ {
+					difference := Intersection(Automaton{graph1, s, target},
+						mustComplement(Automaton{graph2, s, target}, "[a-z]"))
+					for k := uint(0); k < 6; k++ {
+						sequence, found := FindSequence(graph1, graph2, s, target, k)
+						count := CountSequences(difference.Graph, difference.Start, difference.Final, k)
+						if found != (count.Sign() > 0) {
+							t.Errorf("graphs %d, %d: FindSequence(%d, %d, %d) gives %t, g1 ∩ ¬g2 has %d sequences",
+								i, j, s, target, k, found, count)
+						}
+						if found && !difference.Accepts(sequence) {
+							t.Errorf("graphs %d, %d: g1 ∩ ¬g2 rejects %q", i, j, sequence)
+						}
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package lgraph
+
+import (
+	"context"
+	"math/big"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// parallelFor calls f(i) for every i in [0, n) on up to workers goroutines at
+// once, handing out the indices in consecutive chunks, and returns once every
+// call has returned. If workers is not positive it uses GOMAXPROCS goroutines.
+func parallelFor(n, workers int, f func(i int)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers == 1 || n < 2 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+	// a few chunks per worker keep them busy when some states cost more
+	chunk := (n + 4*workers - 1) / (4 * workers)
+	chunks := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, (n+chunk-1)/chunk); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for lo := range chunks {
+				for i := lo; i < min(lo+chunk, n); i++ {
+					f(i)
+				}
+			}
+		}()
+	}
+	for lo := 0; lo < n; lo += chunk {
+		chunks <- lo
+	}
+	close(chunks)
+	wg.Wait()
+}
+
+// frontierState is a node of g1 together with the epsilon-closed set of nodes
+// of g2 that the same prefix reaches, and the moves out of it.
+type frontierState struct {
+	current node
+	others  []node
+	moves   []frontierMove
+}
+
+// frontierMove leads to the state at index next of the same layer if it is an
+// epsilon move, else of the next layer, reading witness.
+type frontierMove struct {
+	epsilon bool
+	witness rune
+	next    int
+}
+
+// rawMove is a move before the state it leads to is numbered.
+type rawMove struct {
+	epsilon bool
+	witness rune
+	current node
+	others  []node
+	key     string
+}
+
+func frontierKey(current node, others []node) string {
+	return strconv.FormatUint(uint64(current), 10) + "|" + nodeSetKey(others)
+}
+
+// FindSequenceParallel is like FindSequence, and returns the same sequence,
+// but explores the states of the search one layer per sequence length at a
+// time, each layer split across up to workers goroutines. If workers is not
+// positive it uses GOMAXPROCS goroutines. The graphs must be safe for
+// concurrent use.
+//
+// The search first builds every layer going forwards, then marks the states
+// that lead to a solution going backwards, and finally replays the depth-first
+// search of FindSequence over the marked states only, so that it finds the
+// same witness.
+func FindSequenceParallel(g1, g2 LGraph, s, t node, k uint, workers int) ([]rune, bool) {
+	if _, exists := g1(s); !exists {
+		return nil, false
+	}
+	var start []node
+	if _, exists := g2(s); exists {
+		start = closure(g2, []node{s})
+	}
+	distance := distancesTo(newMeter(context.Background(), Budget{}), g1, s, t, k)
+	feasible := func(n node, length uint) bool {
+		d, ok := distance[n]
+		return ok && d <= k-length
+	}
+	if !feasible(s, 0) {
+		return nil, false
+	}
+
+	// expand returns the moves out of st in the order FindSequence tries them
+	expand := func(st frontierState, length uint) []rawMove {
+		var result []rawMove
+		edges, _ := g1(st.current)
+		for _, edge := range edges {
+			if edge.epsilon {
+				if feasible(edge.destination, length) {
+					result = append(result, rawMove{
+						epsilon: true,
+						current: edge.destination,
+						others:  st.others,
+						key:     frontierKey(edge.destination, st.others),
+					})
+				}
+				continue
+			}
+			if length == k || !feasible(edge.destination, length+1) {
+				continue
+			}
+			for _, step := range splitLabels(g2, st.others, edge.accepts()) {
+				result = append(result, rawMove{
+					witness: step.witness,
+					current: edge.destination,
+					others:  step.next,
+					key:     frontierKey(edge.destination, step.next),
+				})
+			}
+		}
+		return result
+	}
+
+	layers := [][]frontierState{{{current: s, others: start}}}
+	index := map[string]int{frontierKey(s, start): 0}
+	for length := uint(0); ; length++ {
+		layer := layers[length]
+		var next []frontierState
+		nextIndex := map[string]int{}
+		// epsilon moves add states to the layer itself, so expand it in waves
+		for done := 0; done < len(layer); {
+			wave := layer[done:]
+			raw := make([][]rawMove, len(wave))
+			parallelFor(len(wave), workers, func(i int) {
+				raw[i] = expand(wave[i], length)
+			})
+			for i, rs := range raw {
+				moves := make([]frontierMove, len(rs))
+				for j, r := range rs {
+					states, ids := &layer, index
+					if !r.epsilon {
+						states, ids = &next, nextIndex
+					}
+					n, seen := ids[r.key]
+					if !seen {
+						n = len(*states)
+						ids[r.key] = n
+						*states = append(*states, frontierState{current: r.current, others: r.others})
+					}
+					moves[j] = frontierMove{r.epsilon, r.witness, n}
+				}
+				layer[done+i].moves = moves
+			}
+			done += len(wave)
+		}
+		layers[length] = layer
+		if length == k {
+			break
+		}
+		if len(next) == 0 {
+			return nil, false
+		}
+		layers = append(layers, next)
+		index = nextIndex
+	}
+
+	accepting := func(st frontierState, length int) bool {
+		return uint(length) == k && st.current == t && !containsNode(st.others, t)
+	}
+
+	// a state is good if it leads to a solution
+	good := make([][]bool, len(layers))
+	for length := len(layers) - 1; length >= 0; length-- {
+		layer := layers[length]
+		good[length] = make([]bool, len(layer))
+		parallelFor(len(layer), workers, func(j int) {
+			if accepting(layer[j], length) {
+				good[length][j] = true
+				return
+			}
+			for _, m := range layer[j].moves {
+				if !m.epsilon && good[length+1][m.next] {
+					good[length][j] = true
+					return
+				}
+			}
+		})
+		// then follow the epsilon moves backwards
+		back := make([][]int, len(layer))
+		var queue []int
+		for j, st := range layer {
+			for _, m := range st.moves {
+				if m.epsilon {
+					back[m.next] = append(back[m.next], j)
+				}
+			}
+			if good[length][j] {
+				queue = append(queue, j)
+			}
+		}
+		for len(queue) > 0 {
+			j := queue[0]
+			queue = queue[1:]
+			for _, p := range back[j] {
+				if !good[length][p] {
+					good[length][p] = true
+					queue = append(queue, p)
+				}
+			}
+		}
+	}
+
+	// replay the search of FindSequence; the states it would visit that are
+	// not good all fail and lead only to states that are not good either
+	visited := make([][]bool, len(layers))
+	for length, layer := range layers {
+		visited[length] = make([]bool, len(layer))
+	}
+	// one rune per layer after the first, however large k is
+	sequence := make([]rune, 0, len(layers)-1)
+	var dfs func(length, j int) bool
+	dfs = func(length, j int) bool {
+		if !good[length][j] || visited[length][j] {
+			return false
+		}
+		visited[length][j] = true
+		st := layers[length][j]
+		if accepting(st, length) {
+			return true
+		}
+		for _, m := range st.moves {
+			if m.epsilon {
+				if dfs(length, m.next) {
+					return true
+				}
+				continue
+			}
+			sequence = append(sequence[:length], m.witness)
+			if dfs(length+1, m.next) {
+				return true
+			}
+			sequence = sequence[:length]
+		}
+		return false
+	}
+	if dfs(0, 0) {
+		return sequence, true
+	}
+	return nil, false
+}
+
+// CountSequencesParallel is like CountSequences but counts the sequences one
+// length at a time, splitting the sets of nodes reached by the sequences of
+// each length across up to workers goroutines. If workers is not positive it
+// uses GOMAXPROCS goroutines. The graph must be safe for concurrent use.
+func CountSequencesParallel(g LGraph, s, t node, k uint, workers int) *big.Int {
+	if _, exists := g(s); !exists {
+		return new(big.Int)
+	}
+	// every sequence of a given length reaches exactly one closed set of
+	// nodes, so the counts of the sets add up to the number of sequences
+	type subset struct {
+		nodes []node
+		count *big.Int
+	}
+	type contribution struct {
+		key   string
+		nodes []node
+		count *big.Int
+	}
+	layer := []subset{{closure(g, []node{s}), big.NewInt(1)}}
+	for length := uint(0); length < k && len(layer) > 0; length++ {
+		raw := make([][]contribution, len(layer))
+		parallelFor(len(layer), workers, func(i int) {
+			for _, class := range moves(g, layer[i].nodes) {
+				n := new(big.Int).Mul(big.NewInt(class.runes.size()), layer[i].count)
+				raw[i] = append(raw[i], contribution{nodeSetKey(class.next), class.next, n})
+			}
+		})
+		var next []subset
+		index := map[string]int{}
+		for _, cs := range raw {
+			for _, c := range cs {
+				if j, seen := index[c.key]; seen {
+					next[j].count.Add(next[j].count, c.count)
+					continue
+				}
+				index[c.key] = len(next)
+				next = append(next, subset{c.nodes, c.count})
+			}
+		}
+		layer = next
+	}
+
+	total := new(big.Int)
+	for _, sub := range layer {
+		if containsNode(sub.nodes, t) {
+			total.Add(total, sub.count)
+		}
+	}
+	return total
+}
@@ -0,0 +1,108 @@
+package lgraph
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// The parallel search finds the same witness as FindSequence whatever the
+// number of workers.
+func TestFindSequenceParallel(t *testing.T) {
+	graphs := []LGraph{g1, g2, g3, g4, g5, gSilent, gRunes}
+	for i, graph1 := range graphs {
+		for j, graph2 := range graphs {
+			for s := node(0); s < 8; s++ {
+				for target := node(0); target < 8; target++ {
+					for k := uint(0); k < 6; k++ {
+						expected, expectedExists := FindSequence(graph1, graph2, s, target, k)
+						for _, workers := range []int{1, 3, 0} {
+							sequence, exists := FindSequenceParallel(graph1, graph2, s, target, k, workers)
+							if exists != expectedExists || !reflect.DeepEqual(sequence, expected) {
+								t.Errorf("graphs %d, %d, %d workers: FindSequenceParallel(%d, %d, %d)=(%q, %t); want (%q, %t)",
+									i, j, workers, s, target, k, sequence, exists, expected, expectedExists)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// lengths no sequence reaches, far beyond what fits in memory
+	for _, k := range []uint{1 << 62, 1<<64 - 1} {
+		for _, st := range [][2]node{{2, 0}, {6, 6}} {
+			if sequence, exists := FindSequenceParallel(g1, g2, st[0], st[1], k, 0); sequence != nil || exists {
+				t.Errorf("FindSequenceParallel(%d, %d, %d)=(%q, %t); want (nil, false)", st[0], st[1], k, sequence, exists)
+			}
+		}
+	}
+}
+
+func TestCountSequencesParallel(t *testing.T) {
+	graphs := []LGraph{g1, g2, g3, g4, g5, gRange, gLoop, complete(5)}
+	for i, graph := range graphs {
+		for s := node(0); s < 8; s++ {
+			for target := node(0); target < 8; target++ {
+				for k := uint(0); k < 6; k++ {
+					expected := CountSequences(graph, s, target, k)
+					for _, workers := range []int{1, 3, 0} {
+						if count := CountSequencesParallel(graph, s, target, k, workers); count.Cmp(expected) != 0 {
+							t.Errorf("graph %d, %d workers: CountSequencesParallel(%d, %d, %d)=%d; want %d",
+								i, workers, s, target, k, count, expected)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// wide returns a graph of n nodes with three labels out of every node, so
+// that the search tracks many sets of nodes at once.
+func wide(n node) LGraph {
+	return func(source node) ([]edge, bool) {
+		if source >= n {
+			return nil, false
+		}
+		return []edge{
+			{destination: (source + 1) % n, label: 'a'},
+			{destination: (2 * source) % n, label: 'a'},
+			{destination: (3*source + 1) % n, label: 'b'},
+			{destination: (source + 7) % n, label: 'c'},
+		}, true
+	}
+}
+
+// With g1 = g2 there is no sequence, so the searches explore every state.
+func BenchmarkFindSequence(b *testing.B) {
+	g := wide(500)
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			FindSequence(g, g, 0, 1, 8)
+		}
+	})
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run("workers="+strconv.Itoa(workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				FindSequenceParallel(g, g, 0, 1, 8, workers)
+			}
+		})
+	}
+}
+
+func BenchmarkCountSequences(b *testing.B) {
+	g := wide(500)
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			CountSequences(g, 0, 1, 8)
+		}
+	})
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run("workers="+strconv.Itoa(workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				CountSequencesParallel(g, 0, 1, 8, workers)
+			}
+		})
+	}
+}
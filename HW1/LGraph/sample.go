@@ -0,0 +1,275 @@
+package lgraph
+
+import (
+	"math/big"
+	"math/rand"
+	"strconv"
+)
+
+// Sampler draws label sequences from graphs at random. Two samplers created
+// with the same seed draw the same sequences from the same graphs.
+type Sampler struct {
+	rng *rand.Rand
+}
+
+// NewSampler returns a Sampler whose random choices are determined by seed.
+func NewSampler(seed int64) *Sampler {
+	return &Sampler{rand.New(rand.NewSource(seed))}
+}
+
+// sequenceStates is the graph of the epsilon-closed sets of nodes of g that
+// sequences from a set of nodes can reach, so that every sequence is counted
+// once however many paths read it. The set of nodes started from is state 0.
+type sequenceStates struct {
+	nodes [][]node
+	moves [][]stateMove
+}
+
+// stateMove is a way of leaving a state by one label of runes, to the state
+// to.
+type stateMove struct {
+	runes runeSet
+	to    int
+}
+
+func newSequenceStates(g LGraph, from []node) *sequenceStates {
+	s := &sequenceStates{}
+	index := map[string]int{}
+	add := func(nodes []node) int {
+		key := nodeSetKey(nodes)
+		i, seen := index[key]
+		if !seen {
+			i = len(s.nodes)
+			index[key] = i
+			s.nodes = append(s.nodes, nodes)
+		}
+		return i
+	}
+	add(from)
+	for i := 0; i < len(s.nodes); i++ {
+		var stateMoves []stateMove
+		for _, class := range moves(g, s.nodes[i]) {
+			stateMoves = append(stateMoves, stateMove{class.runes, add(class.next)})
+		}
+		s.moves = append(s.moves, stateMoves)
+	}
+	return s
+}
+
+// counts returns the numbers of sequences of length k from every state to t.
+// It computes them bottom up, a length at a time, and passes keep the counts
+// of every length from 0 to k. Unless keep returns true, and so keeps the
+// counts, their big.Ints are reused two lengths later.
+func (s *sequenceStates) counts(t node, k uint, keep func(length uint, level []*big.Int) bool) []*big.Int {
+	level := make([]*big.Int, len(s.nodes))
+	for i, nodes := range s.nodes {
+		level[i] = new(big.Int)
+		if containsNode(nodes, t) {
+			level[i].SetInt64(1)
+		}
+	}
+	var spare []*big.Int
+	if !keep(0, level) {
+		spare = make([]*big.Int, len(s.nodes))
+		for i := range spare {
+			spare[i] = new(big.Int)
+		}
+	}
+	term := new(big.Int)
+	for length := uint(1); length <= k; length++ {
+		next := spare
+		if next == nil {
+			next = make([]*big.Int, len(s.nodes))
+			for i := range next {
+				next[i] = new(big.Int)
+			}
+		}
+		for i, stateMoves := range s.moves {
+			next[i].SetInt64(0)
+			for _, m := range stateMoves {
+				next[i].Add(next[i], term.Mul(big.NewInt(m.runes.size()), level[m.to]))
+			}
+		}
+		if keep(length, next) {
+			spare = nil
+		} else {
+			spare = level
+		}
+		level = next
+	}
+	return level
+}
+
+// moves returns the ways of leaving the nodes of from in g by one label,
+// leaving out the labels that lead nowhere.
+func moves(g LGraph, from []node) []labelClass {
+	var labels []runeSet
+	for _, n := range from {
+		edges, _ := g(n)
+		for _, edge := range edges {
+			labels = append(labels, edge.accepts())
+		}
+	}
+	var classes []labelClass
+	for _, class := range labelClasses(g, from, union(labels...)) {
+		if len(class.next) > 0 {
+			classes = append(classes, class)
+		}
+	}
+	return classes
+}
+
+// CountSequences returns the number of distinct sequences of length k from
+// node s to node t in graph g. Epsilon edges do not count towards the length,
+// and a set-labeled edge stands for one sequence per rune of its set. It
+// counts the sequences of every length up to k in turn, keeping only those of
+// the length before, so k may be large.
+func CountSequences(g LGraph, s, t node, k uint) *big.Int {
+	if _, exists := g(s); !exists {
+		return new(big.Int)
+	}
+	states := newSequenceStates(g, closure(g, []node{s}))
+	return states.counts(t, k, func(uint, []*big.Int) bool { return false })[0]
+}
+
+// Uniform returns (S, true) where S is drawn uniformly at random from all the
+// distinct sequences of length k from node s to node t in graph g, if there is
+// any; else it returns (nil, false).
+func (r *Sampler) Uniform(g LGraph, s, t node, k uint) ([]rune, bool) {
+	if _, exists := g(s); !exists {
+		return nil, false
+	}
+	states := newSequenceStates(g, closure(g, []node{s}))
+	var levels [][]*big.Int
+	states.counts(t, k, func(_ uint, level []*big.Int) bool {
+		levels = append(levels, level)
+		return true
+	})
+	if levels[k][0].Sign() == 0 {
+		return nil, false
+	}
+
+	// pick every label with probability proportional to the number of ways
+	// of finishing the sequence after it
+	sequence := []rune{}
+	current := 0
+	for left := k; left > 0; left-- {
+		pick := new(big.Int).Rand(r.rng, levels[left][current])
+		for _, m := range states.moves[current] {
+			ways := levels[left-1][m.to]
+			n := new(big.Int).Mul(big.NewInt(m.runes.size()), ways)
+			if pick.Cmp(n) >= 0 {
+				pick.Sub(pick, n)
+				continue
+			}
+			i := new(big.Int).Div(pick, ways).Int64()
+			sequence = append(sequence, m.runes.nth(i))
+			current = m.to
+			break
+		}
+	}
+	return sequence, true
+}
+
+// Walk takes a random walk from node s in graph g until it has read n labels
+// or gets stuck, and returns the path it took. At every node it takes an
+// outgoing edge with probability proportional to bias(node, edge), which must
+// not be negative; if bias is nil, or is zero for every edge, the edges are
+// equally likely. A set-labeled edge reads a rune of its set chosen uniformly
+// at random. The walk never follows an epsilon edge back to a node it has
+// reached since it last read a label, and gets stuck at a node with no other
+// edge to take.
+func (r *Sampler) Walk(g LGraph, s node, n uint, bias func(node, edge) float64) Path {
+	return r.walk(g, s, n, func(from node, edges []edge, candidates []int) int {
+		if bias != nil {
+			total := 0.0
+			weights := make([]float64, len(candidates))
+			for i, c := range candidates {
+				weights[i] = bias(from, edges[c])
+				total += weights[i]
+			}
+			if total > 0 {
+				x := r.rng.Float64() * total
+				for i, w := range weights {
+					if x < w {
+						return candidates[i]
+					}
+					x -= w
+				}
+				// rounding can leave x just above the last weight
+				for i := len(weights) - 1; ; i-- {
+					if weights[i] > 0 {
+						return candidates[i]
+					}
+				}
+			}
+		}
+		return candidates[r.rng.Intn(len(candidates))]
+	})
+}
+
+// Cover takes count walks of up to n labels each from node s in graph g like
+// Walk does, and returns the paths taken. At every node a walk picks uniformly
+// at random among the outgoing edges that no walk has taken yet, and only
+// falls back to the edges already covered when there are none.
+func (r *Sampler) Cover(g LGraph, s node, n uint, count int) []Path {
+	covered := map[string]bool{}
+	edgeKey := func(from node, i int) string {
+		return strconv.FormatUint(uint64(from), 10) + ":" + strconv.Itoa(i)
+	}
+	choose := func(from node, edges []edge, candidates []int) int {
+		var fresh []int
+		for _, c := range candidates {
+			if !covered[edgeKey(from, c)] {
+				fresh = append(fresh, c)
+			}
+		}
+		if len(fresh) > 0 {
+			candidates = fresh
+		}
+		c := candidates[r.rng.Intn(len(candidates))]
+		covered[edgeKey(from, c)] = true
+		return c
+	}
+
+	var paths []Path
+	for i := 0; i < count; i++ {
+		paths = append(paths, r.walk(g, s, n, choose))
+	}
+	return paths
+}
+
+// walk takes a walk from node s in graph g until it has read n labels or gets
+// stuck. At every node, choose picks the edge to take among the candidate
+// indices into the outgoing edges.
+func (r *Sampler) walk(g LGraph, s node, n uint, choose func(node, []edge, []int) int) Path {
+	path := Path{Nodes: []node{s}, Sequence: []rune{}}
+	// nodes reached since the last label
+	silent := map[node]bool{s: true}
+	current := s
+	for uint(len(path.Sequence)) < n {
+		edges, _ := g(current)
+		var candidates []int
+		for i, edge := range edges {
+			if !edge.epsilon || !silent[edge.destination] {
+				candidates = append(candidates, i)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		edge := edges[choose(current, edges, candidates)]
+
+		path.Nodes = append(path.Nodes, edge.destination)
+		path.Weight += edge.weight
+		if edge.epsilon {
+			silent[edge.destination] = true
+		} else {
+			labels := edge.accepts()
+			path.Sequence = append(path.Sequence, labels.nth(r.rng.Int63n(labels.size())))
+			silent = map[node]bool{edge.destination: true}
+		}
+		current = edge.destination
+	}
+	return path
+}
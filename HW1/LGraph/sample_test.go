@@ -0,0 +1,202 @@
+package lgraph
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// two paths read the same sequence "ab"
+var gTwoPaths = mkGraph(map[node][]edge{
+	0: {{destination: 1, label: 'a'}, {destination: 2, label: 'a'}},
+	1: {{destination: 3, label: 'b'}},
+	2: {{destination: 3, label: 'b'}},
+	3: {},
+})
+
+var gSilentLoop = mkGraph(map[node][]edge{
+	0: {epsilonEdge(1)},
+	1: {epsilonEdge(0)},
+})
+
+func TestCountSequences(t *testing.T) {
+	tests := []struct {
+		graph          LGraph
+		source, target node
+		sequenceLength uint
+		expected       int64
+	}{
+		{g1, 0, 6, 5, 2},
+		{g1, 0, 6, 3, 0},
+		{g1, 17, 6, 3, 0},
+		{g1, 7, 7, 0, 1},
+		{g2, 1, 1, 6, 8},
+		{g2, 1, 2, 5, 5},
+		{g4, 0, 4, 2, 26},
+		{g4, 0, 1, 2, 26 * 26},
+		{gTwoPaths, 0, 3, 2, 1},
+	}
+	for i, test := range tests {
+		actual := CountSequences(test.graph, test.source, test.target, test.sequenceLength)
+		if actual.Cmp(big.NewInt(test.expected)) != 0 {
+			t.Errorf("#%d: CountSequences(%d, %d, %d)=%v; want %d", i,
+				test.source, test.target, test.sequenceLength, actual, test.expected)
+		}
+	}
+
+	// the number of sequences of length 100 of the complete graph overflows
+	// an int64
+	expected := new(big.Int).Exp(big.NewInt(3), big.NewInt(100), nil)
+	if actual := CountSequences(complete(3), 0, 1, 101); actual.Cmp(expected) != 0 {
+		t.Errorf("CountSequences(complete(3), 0, 1, 101)=%v; want %v", actual, expected)
+	}
+
+	// a length far beyond any depth of recursion the stack allows
+	if actual := CountSequences(gLoop, 0, 0, 20_000_000); actual.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("CountSequences(gLoop, 0, 0, 20000000)=%v; want 1", actual)
+	}
+}
+
+func TestUniform(t *testing.T) {
+	expected := map[string]bool{
+		"cdddeb": true, "cddeab": true, "cdeaab": true, "ceaaab": true,
+		"cebceb": true, "cebfgh": true, "fghceb": true, "fghfgh": true,
+	}
+	const N = 4000
+	counts := map[string]int{}
+	r := NewSampler(1)
+	for i := 0; i < N; i++ {
+		sequence, exists := r.Uniform(g2, 1, 1, 6)
+		if !exists || !expected[string(sequence)] {
+			t.Fatalf("Uniform(g2, 1, 1, 6)=(%q, %t)", sequence, exists)
+		}
+		counts[string(sequence)]++
+	}
+	for sequence := range expected {
+		if c := counts[sequence]; c < N/8*8/10 || c > N/8*12/10 {
+			t.Errorf("Uniform(g2, 1, 1, 6) gave %q %d times out of %d", sequence, c, N)
+		}
+	}
+
+	// set-labeled edges give every rune a fair chance
+	letters := map[rune]int{}
+	for i := 0; i < 2600; i++ {
+		sequence, exists := r.Uniform(g4, 0, 4, 2)
+		if !exists || len(sequence) != 2 || sequence[1] != 'x' {
+			t.Fatalf("Uniform(g4, 0, 4, 2)=(%q, %t)", sequence, exists)
+		}
+		letters[sequence[0]]++
+	}
+	if len(letters) != 26 {
+		t.Errorf("Uniform(g4, 0, 4, 2) used %d letters; want 26", len(letters))
+	}
+
+	if sequence, exists := r.Uniform(g1, 0, 6, 3); sequence != nil || exists {
+		t.Errorf("Uniform(g1, 0, 6, 3)=(%q, %t); want (nil, false)", sequence, exists)
+	}
+	if sequence, exists := r.Uniform(g1, 17, 17, 0); sequence != nil || exists {
+		t.Errorf("Uniform(g1, 17, 17, 0)=(%q, %t); want (nil, false)", sequence, exists)
+	}
+	if sequence, exists := r.Uniform(g1, 7, 7, 0); !exists || !reflect.DeepEqual(sequence, []rune{}) {
+		t.Errorf("Uniform(g1, 7, 7, 0)=(%q, %t); want (\"\", true)", sequence, exists)
+	}
+}
+
+func TestSamplerSeed(t *testing.T) {
+	r1, r2 := NewSampler(42), NewSampler(42)
+	for i := 0; i < 20; i++ {
+		s1, _ := r1.Uniform(g2, 1, 2, 5)
+		s2, _ := r2.Uniform(g2, 1, 2, 5)
+		if !reflect.DeepEqual(s1, s2) {
+			t.Fatalf("Uniform with the same seed gives %q and %q", s1, s2)
+		}
+		w1 := r1.Walk(g3, 0, 10, nil)
+		w2 := r2.Walk(g3, 0, 10, nil)
+		if !reflect.DeepEqual(w1, w2) {
+			t.Fatalf("Walk with the same seed gives %v and %v", w1, w2)
+		}
+	}
+}
+
+// checkPath reports whether path is a path of g.
+func checkPath(g LGraph, path Path) bool {
+	labels := 0
+	for i := 0; i+1 < len(path.Nodes); i++ {
+		edges, _ := g(path.Nodes[i])
+		found := false
+		for _, edge := range edges {
+			if edge.destination != path.Nodes[i+1] {
+				continue
+			}
+			if edge.epsilon {
+				found = true
+			} else if labels < len(path.Sequence) && edge.accepts().contains(path.Sequence[labels]) {
+				found = true
+				labels++
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return labels == len(path.Sequence)
+}
+
+func TestWalk(t *testing.T) {
+	r := NewSampler(7)
+	for i := 0; i < 100; i++ {
+		path := r.Walk(g3, 0, 8, nil)
+		if len(path.Sequence) != 8 || !checkPath(g3, path) {
+			t.Fatalf("Walk(g3, 0, 8)=%v is not a path of 8 labels", path)
+		}
+	}
+
+	// never take the a edge of node 0
+	noA := func(from node, e edge) float64 {
+		if e.label == 'a' {
+			return 0
+		}
+		return 1
+	}
+	for i := 0; i < 100; i++ {
+		path := r.Walk(g1, 0, 5, noA)
+		if len(path.Sequence) == 0 || path.Sequence[0] != 'b' || !checkPath(g1, path) {
+			t.Fatalf("Walk(g1, 0, 5, noA)=%v", path)
+		}
+	}
+
+	if path := r.Walk(g1, 6, 5, nil); !reflect.DeepEqual(path, Path{[]node{6}, []rune{}, 0}) {
+		t.Errorf("Walk(g1, 6, 5)=%v; want a walk that is stuck at 6", path)
+	}
+	if path := r.Walk(gSilentLoop, 0, 5, nil); !reflect.DeepEqual(path, Path{[]node{0, 1}, []rune{}, 0}) {
+		t.Errorf("Walk(gSilentLoop, 0, 5)=%v; want a walk that is stuck at 1", path)
+	}
+	if path := r.Walk(g4, 0, 3, nil); len(path.Sequence) != 3 || !checkPath(g4, path) {
+		t.Errorf("Walk(g4, 0, 3)=%v is not a path of 3 labels", path)
+	}
+}
+
+func TestCover(t *testing.T) {
+	paths := NewSampler(3).Cover(g1, 0, 5, 6)
+	covered := map[[2]node]bool{}
+	for _, path := range paths {
+		if !checkPath(g1, path) {
+			t.Fatalf("Cover(g1, 0, 5, 6) gave %v, which is not a path", path)
+		}
+		for i := 0; i+1 < len(path.Nodes); i++ {
+			covered[[2]node{path.Nodes[i], path.Nodes[i+1]}] = true
+		}
+	}
+	// every edge reachable from 0 is covered
+	for _, n := range Reachable(g1, 0) {
+		edges, _ := g1(n)
+		for _, edge := range edges {
+			if !covered[[2]node{n, edge.destination}] {
+				t.Errorf("Cover(g1, 0, 5, 6) misses the edge from %d to %d", n, edge.destination)
+			}
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package lgraph
+
+// Reachable returns the sorted set of nodes reachable from s in g, including s
+// itself if it exists. Epsilon and labeled edges are followed alike.
+func Reachable(g LGraph, s node) []node {
+	if _, exists := g(s); !exists {
+		return nil
+	}
+	seen := map[node]bool{s: true}
+	result := []node{s}
+	for i := 0; i < len(result); i++ {
+		edges, _ := g(result[i])
+		for _, edge := range edges {
+			if !seen[edge.destination] {
+				seen[edge.destination] = true
+				result = append(result, edge.destination)
+			}
+		}
+	}
+	sortNodes(result)
+	return result
+}
+
+// SCCs returns the strongly connected components of the part of g reachable
+// from s, computed with Tarjan's algorithm. Every component is sorted, and the
+// components are in reverse topological order: no edge leads from a component
+// to one listed before it.
+func SCCs(g LGraph, s node) [][]node {
+	if _, exists := g(s); !exists {
+		return nil
+	}
+	index := map[node]int{}
+	lowlink := map[node]int{}
+	onStack := map[node]bool{}
+	var stack []node
+	var components [][]node
+
+	var strongConnect func(v node)
+	strongConnect = func(v node) {
+		index[v] = len(index)
+		lowlink[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+
+		edges, _ := g(v)
+		for _, edge := range edges {
+			w := edge.destination
+			if _, visited := index[w]; !visited {
+				strongConnect(w)
+				lowlink[v] = min(lowlink[v], lowlink[w])
+			} else if onStack[w] {
+				lowlink[v] = min(lowlink[v], index[w])
+			}
+		}
+
+		// v is the root of a component: pop it off the stack
+		if lowlink[v] == index[v] {
+			var component []node
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component = append(component, w)
+				if w == v {
+					break
+				}
+			}
+			sortNodes(component)
+			components = append(components, component)
+		}
+	}
+	strongConnect(s)
+	return components
+}
+
+// FindCycle returns (C, true) if the part of g reachable from s has a cycle,
+// where C lists the nodes of the cycle in order, and the last node has an
+// edge back to the first; else it returns (nil, false).
+func FindCycle(g LGraph, s node) ([]node, bool) {
+	if _, exists := g(s); !exists {
+		return nil, false
+	}
+	const (
+		unvisited = iota
+		active
+		done
+	)
+	color := map[node]int{}
+	var path []node
+
+	var dfs func(current node) []node
+	dfs = func(current node) []node {
+		color[current] = active
+		path = append(path, current)
+		edges, _ := g(current)
+		for _, edge := range edges {
+			switch color[edge.destination] {
+			case active:
+				// the cycle is the part of the path from the destination on
+				for i := len(path) - 1; ; i-- {
+					if path[i] == edge.destination {
+						return append([]node(nil), path[i:]...)
+					}
+				}
+			case unvisited:
+				if cycle := dfs(edge.destination); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		color[current] = done
+		path = path[:len(path)-1]
+		return nil
+	}
+	if cycle := dfs(s); cycle != nil {
+		return cycle, true
+	}
+	return nil, false
+}
+
+// TopologicalOrder returns (O, true) if the part of g reachable from s is
+// acyclic, where O lists its nodes so that every edge leads from a node to one
+// listed after it; else it returns (nil, false).
+func TopologicalOrder(g LGraph, s node) ([]node, bool) {
+	if _, cyclic := FindCycle(g, s); cyclic {
+		return nil, false
+	}
+	components := SCCs(g, s)
+	var order []node
+	for i := len(components) - 1; i >= 0; i-- {
+		order = append(order, components[i][0])
+	}
+	return order, true
+}
+
+// Trim returns the subgraph of g with only the nodes that are reachable from s
+// and from which t is reachable, and only the edges between such nodes.
+func Trim(g LGraph, s, t node) LGraph {
+	keep := map[node]bool{}
+	reachable := Reachable(g, s)
+	preds := predecessors(g, reachable)
+	if containsNode(reachable, t) {
+		keep[t] = true
+		queue := []node{t}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, p := range preds[current] {
+				if !keep[p] {
+					keep[p] = true
+					queue = append(queue, p)
+				}
+			}
+		}
+	}
+
+	return func(n node) ([]edge, bool) {
+		if !keep[n] {
+			return nil, false
+		}
+		edges, _ := g(n)
+		var result []edge
+		for _, edge := range edges {
+			if keep[edge.destination] {
+				result = append(result, edge)
+			}
+		}
+		return result, true
+	}
+}
+
+// predecessors returns, for every node of nodes, the nodes of nodes with an
+// edge to it.
+func predecessors(g LGraph, nodes []node) map[node][]node {
+	preds := map[node][]node{}
+	for _, n := range nodes {
+		edges, _ := g(n)
+		for _, edge := range edges {
+			preds[edge.destination] = append(preds[edge.destination], n)
+		}
+	}
+	return preds
+}
+
+// distancesTo returns, for every node reachable from s by at most k labeled
+// edges in g, the least number of labeled edges on a path from it to t. Nodes
+// that cannot reach t within those bounds are left out. Every node explored is
+// reported to m.
+func distancesTo(m *meter, g LGraph, s, t node, k uint) map[node]uint {
+	// explore forwards from s, epsilon edges first, recording the edges
+	// backwards
+	type backEdge struct {
+		from    node
+		epsilon bool
+	}
+	back := map[node][]backEdge{}
+	from := map[node]uint{s: 0}
+	explored := map[node]bool{}
+	queue := []node{s}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if explored[current] {
+			continue
+		}
+		explored[current] = true
+		if !m.visit(0) {
+			return nil
+		}
+		edges, _ := g(current)
+		for _, edge := range edges {
+			back[edge.destination] = append(back[edge.destination], backEdge{current, edge.epsilon})
+			d := from[current]
+			if !edge.epsilon {
+				d++
+			}
+			if old, seen := from[edge.destination]; d <= k && (!seen || d < old) {
+				from[edge.destination] = d
+				if edge.epsilon {
+					queue = append([]node{edge.destination}, queue...)
+				} else {
+					queue = append(queue, edge.destination)
+				}
+			}
+		}
+	}
+
+	// then backwards from t
+	to := map[node]uint{}
+	if _, seen := from[t]; !seen {
+		return to
+	}
+	to[t] = 0
+	queue = []node{t}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, b := range back[current] {
+			d := to[current]
+			if !b.epsilon {
+				d++
+			}
+			if old, seen := to[b.from]; !seen || d < old {
+				to[b.from] = d
+				if b.epsilon {
+					queue = append([]node{b.from}, queue...)
+				} else {
+					queue = append(queue, b.from)
+				}
+			}
+		}
+	}
+	m.use(uint64(len(from)+len(to)) * stateSize)
+	return to
+}
@@ -0,0 +1,140 @@
+package lgraph
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+var gTriangle = mkGraph(map[node][]edge{
+	0: {{destination: 1, label: 'a'}, {destination: 3, label: 'd'}},
+	1: {{destination: 2, label: 'b'}},
+	2: {{destination: 0, label: 'c'}},
+	3: {},
+})
+
+func TestReachable(t *testing.T) {
+	tests := []struct {
+		graph    LGraph
+		source   node
+		expected []node
+	}{
+		{g1, 0, []node{0, 1, 2, 3, 4, 6, 7}},
+		{g1, 3, []node{3, 4, 6, 7}},
+		{g1, 6, []node{6}},
+		{g1, 17, nil},
+		{g4, 2, []node{0, 1, 2, 3, 4}},
+	}
+	for i, test := range tests {
+		if actual := Reachable(test.graph, test.source); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("#%d: Reachable(%d)=%v; want %v", i, test.source, actual, test.expected)
+		}
+	}
+}
+
+func TestSCCs(t *testing.T) {
+	tests := []struct {
+		graph    LGraph
+		source   node
+		expected [][]node
+	}{
+		{g1, 0, [][]node{{2}, {6}, {7}, {4}, {3}, {1}, {0}}},
+		{g2, 3, [][]node{{6}, {5}, {0, 1, 2, 3, 4}}},
+		{g3, 0, [][]node{{0, 1, 2, 3, 4}}},
+		{gTriangle, 0, [][]node{{3}, {0, 1, 2}}},
+		{g1, 17, nil},
+	}
+	for i, test := range tests {
+		if actual := SCCs(test.graph, test.source); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("#%d: SCCs(%d)=%v; want %v", i, test.source, actual, test.expected)
+		}
+	}
+}
+
+func TestFindCycle(t *testing.T) {
+	tests := []struct {
+		graph          LGraph
+		source         node
+		expected       []node
+		expectedExists bool
+	}{
+		{g1, 0, []node{0}, true},
+		{g1, 1, nil, false},
+		{g2, 1, []node{2}, true},
+		{g3, 3, []node{0}, true},
+		{gTriangle, 0, []node{0, 1, 2}, true},
+		{gTriangle, 1, []node{1, 2, 0}, true},
+		{gTriangle, 3, nil, false},
+		{g4, 0, []node{0, 1, 2}, true},
+		{g1, 17, nil, false},
+	}
+	for i, test := range tests {
+		actual, exists := FindCycle(test.graph, test.source)
+		if exists != test.expectedExists || !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("#%d: FindCycle(%d)=(%v, %t); want (%v, %t)", i,
+				test.source, actual, exists, test.expected, test.expectedExists)
+		}
+	}
+}
+
+func TestTopologicalOrder(t *testing.T) {
+	tests := []struct {
+		graph          LGraph
+		source         node
+		expected       []node
+		expectedExists bool
+	}{
+		{g1, 1, []node{1, 3, 4, 7, 6, 2}, true},
+		{g1, 6, []node{6}, true},
+		{g1, 0, nil, false},
+		{gTriangle, 0, nil, false},
+		{g1, 17, nil, true},
+	}
+	for i, test := range tests {
+		actual, exists := TopologicalOrder(test.graph, test.source)
+		if exists != test.expectedExists || !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("#%d: TopologicalOrder(%d)=(%v, %t); want (%v, %t)", i,
+				test.source, actual, exists, test.expected, test.expectedExists)
+		}
+	}
+}
+
+func TestTrim(t *testing.T) {
+	trimmed := Trim(g1, 0, 7)
+	if actual := Reachable(trimmed, 0); !reflect.DeepEqual(actual, []node{0, 1, 3, 4, 7}) {
+		t.Errorf("Reachable(Trim(g1, 0, 7), 0)=%v; want %v", actual, []node{0, 1, 3, 4, 7})
+	}
+	if edges, exists := trimmed(1); !exists || !reflect.DeepEqual(edges, []edge{{destination: 3, label: 'f'}}) {
+		t.Errorf("Trim(g1, 0, 7)(1)=(%v, %t)", edges, exists)
+	}
+	for _, n := range []node{2, 6, 17} {
+		if _, exists := trimmed(n); exists {
+			t.Errorf("Trim(g1, 0, 7)(%d) exists", n)
+		}
+	}
+	if actual := Reachable(Trim(g1, 3, 2), 3); actual != nil {
+		t.Errorf("Reachable(Trim(g1, 3, 2), 3)=%v; want []", actual)
+	}
+}
+
+func TestDistancesTo(t *testing.T) {
+	tests := []struct {
+		graph          LGraph
+		source, target node
+		bound          uint
+		expected       map[node]uint
+	}{
+		{g1, 0, 6, 5, map[node]uint{0: 4, 1: 3, 3: 2, 4: 2, 7: 1, 6: 0}},
+		{g1, 0, 6, 3, map[node]uint{}},
+		{g1, 1, 7, 2, map[node]uint{1: 2, 3: 1, 4: 1, 7: 0}},
+		{g4, 0, 3, 1, map[node]uint{0: 1, 1: 1, 2: 0, 3: 0}},
+	}
+	for i, test := range tests {
+		m := newMeter(context.Background(), Budget{})
+		actual := distancesTo(m, test.graph, test.source, test.target, test.bound)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("#%d: distancesTo(%d, %d, %d)=%v; want %v", i,
+				test.source, test.target, test.bound, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package lgraph
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrNotCTL is the error value returned by CheckCTL if a temporal operator of
+// the property is not directly under a path quantifier, or a path quantifier
+// is not directly over a temporal operator.
+var ErrNotCTL = errors.New("property is not in CTL")
+
+// ErrNotLTL is the error value returned by the LTL checks if the property has
+// a path quantifier.
+var ErrNotLTL = errors.New("property is not in LTL")
+
+// Property is a temporal logic property of the traces of a graph. A trace is
+// an infinite sequence of labels read along a path; epsilon edges read
+// nothing, and a set-labeled edge reads any rune of its set. At every position
+// of a trace, Label(set) holds iff the label read there is in set.
+//
+// Properties are built with the functions below in the syntax of CTL*, and
+// checked with CheckCTL if they are in CTL or with CheckLTL if they are in
+// LTL.
+type Property struct {
+	op   propertyOp
+	set  string
+	args []Property
+}
+
+type propertyOp int
+
+const (
+	propertyTrue propertyOp = iota
+	propertyLabel
+	propertyNot
+	propertyAnd
+	propertyOr
+	propertyNext
+	propertyUntil
+	propertyRelease
+	propertyEventually
+	propertyAlways
+	propertyExists
+	propertyForAll
+)
+
+// True returns the property that always holds.
+func True() Property { return Property{op: propertyTrue} }
+
+// Label returns the property that holds where the label read is in set, which
+// is a rune set such as "[a-z]". Invalid sets are reported by the checks.
+func Label(set string) Property { return Property{op: propertyLabel, set: set} }
+
+// Not returns the negation of p.
+func Not(p Property) Property { return Property{op: propertyNot, args: []Property{p}} }
+
+// And returns the conjunction of p and q.
+func And(p, q Property) Property { return Property{op: propertyAnd, args: []Property{p, q}} }
+
+// Or returns the disjunction of p and q.
+func Or(p, q Property) Property { return Property{op: propertyOr, args: []Property{p, q}} }
+
+// Implies returns the property that q holds if p does.
+func Implies(p, q Property) Property { return Or(Not(p), q) }
+
+// Next returns the property that p holds at the next position (X p).
+func Next(p Property) Property { return Property{op: propertyNext, args: []Property{p}} }
+
+// Until returns the property that q holds at some position, and p holds at
+// every position before it (p U q).
+func Until(p, q Property) Property { return Property{op: propertyUntil, args: []Property{p, q}} }
+
+// Release returns the property that q holds up to and including the first
+// position where p holds, or forever if there is none (p R q).
+func Release(p, q Property) Property {
+	return Property{op: propertyRelease, args: []Property{p, q}}
+}
+
+// Eventually returns the property that p holds at some position (F p).
+func Eventually(p Property) Property { return Property{op: propertyEventually, args: []Property{p}} }
+
+// Always returns the property that p holds at every position (G p).
+func Always(p Property) Property { return Property{op: propertyAlways, args: []Property{p}} }
+
+// Exists returns the property that some trace from the current position
+// satisfies p (E p).
+func Exists(p Property) Property { return Property{op: propertyExists, args: []Property{p}} }
+
+// ForAll returns the property that every trace from the current position
+// satisfies p (A p).
+func ForAll(p Property) Property { return Property{op: propertyForAll, args: []Property{p}} }
+
+// String formats p, e.g. ForAll(Always(Implies(Label("[a]"),
+// Eventually(Label("[b]"))))) is formatted as "AG(![a] | F[b])".
+func (p Property) String() string {
+	var b strings.Builder
+	p.format(&b)
+	return b.String()
+}
+
+func (p Property) format(b *strings.Builder) {
+	prefix := map[propertyOp]string{
+		propertyNot:        "!",
+		propertyNext:       "X",
+		propertyEventually: "F",
+		propertyAlways:     "G",
+		propertyExists:     "E",
+		propertyForAll:     "A",
+	}
+	infix := map[propertyOp]string{
+		propertyAnd:     " & ",
+		propertyOr:      " | ",
+		propertyUntil:   " U ",
+		propertyRelease: " R ",
+	}
+	switch p.op {
+	case propertyTrue:
+		b.WriteString("true")
+	case propertyLabel:
+		b.WriteString(p.set)
+	default:
+		if op, ok := prefix[p.op]; ok {
+			b.WriteString(op)
+			p.args[0].format(b)
+			return
+		}
+		b.WriteByte('(')
+		p.args[0].format(b)
+		b.WriteString(infix[p.op])
+		p.args[1].format(b)
+		b.WriteByte(')')
+	}
+}
+
+// temporal returns true iff p is a temporal operator.
+func (p Property) temporal() bool {
+	switch p.op {
+	case propertyNext, propertyUntil, propertyRelease, propertyEventually, propertyAlways:
+		return true
+	}
+	return false
+}
+
+// labelSets parses the rune sets of the labels of p, appending them to sets.
+func (p Property) labelSets(sets []runeSet) ([]runeSet, error) {
+	if p.op == propertyLabel {
+		set, err := parseRuneSet(p.set)
+		if err != nil {
+			return nil, err
+		}
+		return append(sets, set), nil
+	}
+	for _, arg := range p.args {
+		var err error
+		if sets, err = arg.labelSets(sets); err != nil {
+			return nil, err
+		}
+	}
+	return sets, nil
+}
+
+// refine splits set into the largest pieces whose runes are in the same sets
+// of labels. Empty pieces are left out.
+func refine(set runeSet, labels []runeSet) []runeSet {
+	pieces := []runeSet{set}
+	for _, label := range labels {
+		var next []runeSet
+		for _, piece := range pieces {
+			for _, part := range []runeSet{intersect(piece, label), minus(piece, label)} {
+				if len(part) > 0 {
+					next = append(next, part)
+				}
+			}
+		}
+		pieces = next
+	}
+	return pieces
+}
@@ -0,0 +1,411 @@
+package lgraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"strconv"
+)
+
+// Coverage is a criterion that a generated test suite meets. Every criterion
+// includes the ones before it.
+type Coverage int
+
+const (
+	// NodeCoverage visits every node.
+	NodeCoverage Coverage = iota
+	// EdgeCoverage takes every edge.
+	EdgeCoverage
+	// EdgePairCoverage takes every edge followed by every edge leaving its
+	// destination.
+	EdgePairCoverage
+)
+
+// TestCase is a test generated from a specification graph: the path, which
+// starts at the source, and whether its sequence is a sequence from the source
+// to the target of the specification.
+type TestCase struct {
+	Path
+	Accepted bool
+}
+
+// MarshalJSON encodes c as an object with its sequence as a string, e.g.
+// {"sequence":"ab","nodes":[0,1,2],"accepted":true}.
+func (c TestCase) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Sequence string `json:"sequence"`
+		Nodes    []node `json:"nodes"`
+		Accepted bool   `json:"accepted"`
+	}{string(c.Sequence), c.Nodes, c.Accepted})
+}
+
+// FormatGoTests returns the gofmt-ed Go source of a table of cases, declared
+// as a variable called name of type []struct{sequence string; accepted bool}.
+func FormatGoTests(name string, cases []TestCase) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "var %s = []struct {\n\tsequence string\n\taccepted bool\n}{\n", name)
+	for _, c := range cases {
+		fmt.Fprintf(&b, "\t{%s, %t},\n", strconv.Quote(string(c.Sequence)), c.Accepted)
+	}
+	b.WriteString("}\n")
+	return format.Source(b.Bytes())
+}
+
+// coverageGoal is a node to visit if steps is empty, else consecutive steps to
+// take.
+type coverageGoal struct {
+	node  node
+	steps []step
+}
+
+func goalKey(n node, steps []step) string {
+	if len(steps) == 0 {
+		return "n" + strconv.FormatUint(uint64(n), 10)
+	}
+	return "s" + stepsKey(steps)
+}
+
+// labelCount returns the number of labeled edges among steps.
+func labelCount(steps []step) uint {
+	count := uint(0)
+	for _, st := range steps {
+		if !st.edge.epsilon {
+			count++
+		}
+	}
+	return count
+}
+
+// GenerateTests returns a suite of paths from s in g with at most k labeled
+// edges each that together meet the coverage criterion for the part of g
+// within k labels of s. Each goal not yet met is reached by a path with as few
+// labels as possible, which is then extended for as long as it meets more
+// goals. Every test case records whether its sequence leads from s to t.
+func GenerateTests(g LGraph, s, t node, k uint, c Coverage) []TestCase {
+	if _, exists := g(s); !exists {
+		return nil
+	}
+
+	// the fewest labels needed to reach every node, and how
+	dist := map[node]uint{s: 0}
+	prev := map[node]step{}
+	var order []node
+	done := map[node]bool{}
+	queue := []node{s}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if done[current] {
+			continue
+		}
+		done[current] = true
+		order = append(order, current)
+		edges, _ := g(current)
+		for i, e := range edges {
+			d := dist[current]
+			if !e.epsilon {
+				d++
+			}
+			if old, seen := dist[e.destination]; d > k || seen && old <= d {
+				continue
+			}
+			dist[e.destination] = d
+			prev[e.destination] = step{current, i, e}
+			if e.epsilon {
+				queue = append([]node{e.destination}, queue...)
+			} else {
+				queue = append(queue, e.destination)
+			}
+		}
+	}
+	pathTo := func(n node) []step {
+		var steps []step
+		for ; n != s; n = prev[n].from {
+			steps = append(steps, prev[n])
+		}
+		for a, b := 0, len(steps)-1; a < b; a, b = a+1, b-1 {
+			steps[a], steps[b] = steps[b], steps[a]
+		}
+		return steps
+	}
+
+	// the goals, in the order the nodes were reached
+	var goals []coverageGoal
+	for _, n := range order {
+		goals = append(goals, coverageGoal{node: n})
+	}
+	if c >= EdgeCoverage {
+		for _, n := range order {
+			edges, _ := g(n)
+			for i, e := range edges {
+				st := []step{{n, i, e}}
+				if dist[n]+labelCount(st) <= k {
+					goals = append(goals, coverageGoal{steps: st})
+				}
+			}
+		}
+	}
+	if c >= EdgePairCoverage {
+		for _, n := range order {
+			edges, _ := g(n)
+			for i, e := range edges {
+				next, _ := g(e.destination)
+				for j, f := range next {
+					st := []step{{n, i, e}, {e.destination, j, f}}
+					if dist[n]+labelCount(st) <= k {
+						goals = append(goals, coverageGoal{steps: st})
+					}
+				}
+			}
+		}
+	}
+
+	covered := map[string]bool{}
+	// cover records the goals that taking st after last meets, and returns
+	// true if any of them is new
+	cover := func(last []step, st step) bool {
+		keys := []string{goalKey(st.edge.destination, nil)}
+		if c >= EdgeCoverage {
+			keys = append(keys, goalKey(0, []step{st}))
+		}
+		if c >= EdgePairCoverage && len(last) > 0 {
+			keys = append(keys, goalKey(0, []step{last[len(last)-1], st}))
+		}
+		met := false
+		for _, key := range keys {
+			if !covered[key] {
+				covered[key] = true
+				met = true
+			}
+		}
+		return met
+	}
+
+	var cases []TestCase
+	for i, goal := range goals {
+		if covered[goalKey(goal.node, goal.steps)] {
+			continue
+		}
+		if i == 0 {
+			// every test visits the source
+			covered[goalKey(s, nil)] = true
+		}
+		from := goal.node
+		if len(goal.steps) > 0 {
+			from = goal.steps[0].from
+		}
+		steps := pathTo(from)
+		for j := range steps {
+			cover(steps[:j], steps[j])
+		}
+		for _, st := range goal.steps {
+			cover(steps, st)
+			steps = append(steps, st)
+		}
+
+		// keep going while some edge meets a new goal
+		for extended := true; extended; {
+			extended = false
+			current := s
+			if len(steps) > 0 {
+				current = steps[len(steps)-1].edge.destination
+			}
+			edges, _ := g(current)
+			for j, e := range edges {
+				st := step{current, j, e}
+				if labelCount(steps)+labelCount([]step{st}) <= k && cover(steps, st) {
+					steps = append(steps, st)
+					extended = true
+					break
+				}
+			}
+		}
+
+		path := makePath(s, steps)
+		cases = append(cases, TestCase{path, Automaton{g, s, t}.Accepts(path.Sequence)})
+	}
+	return cases
+}
+
+// TransitionTour returns (P, true) if the part of g reachable from s is
+// strongly connected, where P is a shortest path from s back to s that takes
+// every edge of that part at least once; else it returns (Path{}, false). It
+// solves the Chinese postman problem: edges are taken again along shortest
+// paths from the nodes with more incoming than outgoing edges to those with
+// more outgoing than incoming ones, chosen by a minimum cost flow, and the
+// tour is an Euler circuit of the result.
+func TransitionTour(g LGraph, s node) (Path, bool) {
+	if _, exists := g(s); !exists || len(SCCs(g, s)) != 1 {
+		return Path{}, false
+	}
+	nodes := Reachable(g, s)
+	out := map[node][]step{}
+	balance := map[node]int{}
+	for _, n := range nodes {
+		edges, _ := g(n)
+		for i, e := range edges {
+			out[n] = append(out[n], step{n, i, e})
+			balance[n]--
+			balance[e.destination]++
+		}
+	}
+
+	// shortest paths from every node with more incoming edges
+	var sources, sinks []node
+	for _, n := range nodes {
+		if balance[n] > 0 {
+			sources = append(sources, n)
+		} else if balance[n] < 0 {
+			sinks = append(sinks, n)
+		}
+	}
+	prev := map[node]map[node]step{}
+	for _, u := range sources {
+		prev[u] = map[node]step{}
+		seen := map[node]bool{u: true}
+		queue := []node{u}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, st := range out[current] {
+				if !seen[st.edge.destination] {
+					seen[st.edge.destination] = true
+					prev[u][st.edge.destination] = st
+					queue = append(queue, st.edge.destination)
+				}
+			}
+		}
+	}
+	pathBetween := func(u, v node) []step {
+		var steps []step
+		for n := v; n != u; n = prev[u][n].from {
+			steps = append(steps, prev[u][n])
+		}
+		for a, b := 0, len(steps)-1; a < b; a, b = a+1, b-1 {
+			steps[a], steps[b] = steps[b], steps[a]
+		}
+		return steps
+	}
+
+	cost := make([][]int, len(sources))
+	for i, u := range sources {
+		cost[i] = make([]int, len(sinks))
+		for j, v := range sinks {
+			cost[i][j] = len(pathBetween(u, v))
+		}
+	}
+	supply := make([]int, len(sources))
+	for i, u := range sources {
+		supply[i] = balance[u]
+	}
+	demand := make([]int, len(sinks))
+	for j, v := range sinks {
+		demand[j] = -balance[v]
+	}
+	for i, units := range transport(supply, demand, cost) {
+		for j, n := range units {
+			for ; n > 0; n-- {
+				for _, st := range pathBetween(sources[i], sinks[j]) {
+					out[st.from] = append(out[st.from], st)
+				}
+			}
+		}
+	}
+
+	// Hierholzer's algorithm
+	type frame struct {
+		at  node
+		via step
+	}
+	taken := map[node]int{}
+	stack := []frame{{at: s}}
+	var circuit []step
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if taken[top.at] < len(out[top.at]) {
+			st := out[top.at][taken[top.at]]
+			taken[top.at]++
+			stack = append(stack, frame{st.edge.destination, st})
+			continue
+		}
+		stack = stack[:len(stack)-1]
+		if len(stack) > 0 {
+			circuit = append(circuit, top.via)
+		}
+	}
+	for a, b := 0, len(circuit)-1; a < b; a, b = a+1, b-1 {
+		circuit[a], circuit[b] = circuit[b], circuit[a]
+	}
+	return makePath(s, circuit), true
+}
+
+// transport returns how many units to send from every source to every sink so
+// that each source sends its supply and each sink receives its demand at the
+// least total cost, where cost[i][j] is the cost of a unit from source i to
+// sink j. Supply and demand must have the same total. It uses successive
+// shortest paths in the residual network, found with Bellman-Ford.
+func transport(supply, demand []int, cost [][]int) [][]int {
+	m, n := len(supply), len(demand)
+	flow := make([][]int, m)
+	for i := range flow {
+		flow[i] = make([]int, n)
+	}
+	sent := make([]int, m)
+	received := make([]int, n)
+	// network nodes: 0 is the source, 1..m the sources, m+1..m+n the sinks,
+	// m+n+1 the sink
+	total := m + n + 2
+	for {
+		const inf = int(^uint(0) >> 2)
+		dist := make([]int, total)
+		parent := make([]int, total)
+		for x := range dist {
+			dist[x], parent[x] = inf, -1
+		}
+		dist[0] = 0
+		relax := func(from, to, c int) bool {
+			if dist[from] < inf && dist[from]+c < dist[to] {
+				dist[to], parent[to] = dist[from]+c, from
+				return true
+			}
+			return false
+		}
+		for changed := true; changed; {
+			changed = false
+			for i := 0; i < m; i++ {
+				if sent[i] < supply[i] {
+					changed = relax(0, 1+i, 0) || changed
+				}
+				for j := 0; j < n; j++ {
+					changed = relax(1+i, 1+m+j, cost[i][j]) || changed
+					if flow[i][j] > 0 {
+						changed = relax(1+m+j, 1+i, -cost[i][j]) || changed
+					}
+				}
+			}
+			for j := 0; j < n; j++ {
+				if received[j] < demand[j] {
+					changed = relax(1+m+j, total-1, 0) || changed
+				}
+			}
+		}
+		if dist[total-1] == inf {
+			return flow
+		}
+		// send one unit along the path
+		for x := total - 1; parent[x] >= 0; x = parent[x] {
+			from := parent[x]
+			switch {
+			case from == 0:
+				sent[x-1]++
+			case x == total-1:
+				received[from-1-m]++
+			case from <= m:
+				flow[from-1][x-1-m]++
+			default:
+				flow[x-1][from-1-m]--
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package lgraph
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTests(t *testing.T) {
+	tests := []struct {
+		graph          LGraph
+		source, target node
+		k              uint
+		coverage       Coverage
+		expected       []string // sequences
+		accepted       []bool
+	}{
+		{gLight, 0, 0, 5, NodeCoverage, []string{"gy"}, []bool{false}},
+		{gLight, 0, 0, 1, NodeCoverage, []string{"g"}, []bool{false}},
+		{gCommit, 0, 1, 3, NodeCoverage, []string{"b"}, []bool{true}},
+		{gCommit, 0, 1, 3, EdgeCoverage, []string{"abc"}, []bool{true}},
+		{gCommit, 0, 1, 3, EdgePairCoverage, []string{"aab", "bcc"}, []bool{true, true}},
+		{gCommit, 0, 0, 3, EdgePairCoverage, []string{"aab", "bcc"}, []bool{false, false}},
+		{gStuck, 0, 2, 2, EdgeCoverage, []string{"ab", "ac"}, []bool{true, false}},
+		{gCommit, 7, 1, 3, EdgeCoverage, nil, nil},
+	}
+	for i, test := range tests {
+		cases := GenerateTests(test.graph, test.source, test.target, test.k, test.coverage)
+		var sequences []string
+		var accepted []bool
+		for _, c := range cases {
+			sequences = append(sequences, string(c.Sequence))
+			accepted = append(accepted, c.Accepted)
+		}
+		if strings.Join(sequences, ",") != strings.Join(test.expected, ",") ||
+			len(accepted) != len(test.accepted) {
+			t.Errorf("#%d: GenerateTests(%d, %d, %d, %d) gives %q; want %q", i,
+				test.source, test.target, test.k, test.coverage, sequences, test.expected)
+			continue
+		}
+		for j := range accepted {
+			if accepted[j] != test.accepted[j] {
+				t.Errorf("#%d: GenerateTests(%d, %d, %d, %d)[%d].Accepted=%t; want %t", i,
+					test.source, test.target, test.k, test.coverage, j, accepted[j], test.accepted[j])
+			}
+		}
+	}
+}
+
+// The suites take every two edges in a row that a path of up to k labels from
+// the source takes.
+func TestGenerateTestsCoverage(t *testing.T) {
+	graphs := []LGraph{g1, g2, g3, g4, g5}
+	for i, graph := range graphs {
+		for _, k := range []uint{0, 2, 4} {
+			cases := GenerateTests(graph, 0, 6, k, EdgePairCoverage)
+			taken := map[[3]node]bool{}
+			for _, c := range cases {
+				if uint(len(c.Sequence)) > k {
+					t.Errorf("graph %d, k=%d: test %q is too long", i, k, c.Sequence)
+				}
+				for j := 0; j+2 < len(c.Nodes); j++ {
+					taken[[3]node{c.Nodes[j], c.Nodes[j+1], c.Nodes[j+2]}] = true
+				}
+			}
+			for target := node(0); target < 8; target++ {
+				for length := uint(0); length <= k; length++ {
+					for _, path := range findPaths(graph, 0, target, length) {
+						nodes := []node{0}
+						for _, e := range path {
+							nodes = append(nodes, e.destination)
+						}
+						for j := 0; j+2 < len(nodes); j++ {
+							if pair := [3]node{nodes[j], nodes[j+1], nodes[j+2]}; !taken[pair] {
+								t.Errorf("graph %d, k=%d: %d -> %d -> %d is not covered", i, k, pair[0], pair[1], pair[2])
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestTransitionTour(t *testing.T) {
+	// a and b both lead to 1, from where c leads back
+	gUnbalanced := mkGraph(map[node][]edge{
+		0: {{destination: 1, label: 'a'}, {destination: 1, label: 'b'}},
+		1: {{destination: 0, label: 'c'}},
+	})
+	tests := []struct {
+		graph    LGraph
+		source   node
+		expected string
+		exists   bool
+	}{
+		{gLight, 0, "gyr", true},
+		{gLight, 1, "yrg", true},
+		{gUnbalanced, 0, "acbc", true},
+		{gLoop2, 0, "aa", true},
+		{gCommit, 0, "", false},
+		{gRange, 0, "", false},
+		{g2, 0, "", false},
+		{gLight, 7, "", false},
+	}
+	for i, test := range tests {
+		path, exists := TransitionTour(test.graph, test.source)
+		if exists != test.exists || string(path.Sequence) != test.expected {
+			t.Errorf("#%d: TransitionTour(%d)=(%q, %t); want (%q, %t)", i, test.source, path.Sequence, exists, test.expected, test.exists)
+		}
+		if exists && (path.Nodes[0] != test.source || path.Nodes[len(path.Nodes)-1] != test.source) {
+			t.Errorf("#%d: TransitionTour(%d) goes from %d to %d", i, test.source, path.Nodes[0], path.Nodes[len(path.Nodes)-1])
+		}
+	}
+}
+
+// g3 is strongly connected but unbalanced; its tour takes every edge.
+func TestTransitionTourCoversEdges(t *testing.T) {
+	path, exists := TransitionTour(g3, 0)
+	if !exists {
+		t.Fatal("TransitionTour(g3, 0) finds no tour")
+	}
+	taken := map[[2]node]int{}
+	for i := 0; i+1 < len(path.Nodes); i++ {
+		taken[[2]node{path.Nodes[i], path.Nodes[i+1]}]++
+	}
+	edgeCount := 0
+	for _, n := range Reachable(g3, 0) {
+		edges, _ := g3(n)
+		for _, e := range edges {
+			edgeCount++
+			if taken[[2]node{n, e.destination}] == 0 {
+				t.Errorf("TransitionTour(g3, 0) does not take %d -> %d", n, e.destination)
+			}
+		}
+	}
+	// node 4 has one more incoming than outgoing edge and node 1 one more
+	// outgoing than incoming, so the tour takes 4 -> 1 twice
+	if want := edgeCount + 1; len(path.Sequence) != want {
+		t.Errorf("TransitionTour(g3, 0) has %d edges; want %d", len(path.Sequence), want)
+	}
+}
+
+func TestTestCaseExport(t *testing.T) {
+	cases := GenerateTests(gCommit, 0, 1, 3, EdgeCoverage)
+	data, err := json.Marshal(cases)
+	expected := `[{"sequence":"abc","nodes":[0,0,1,1],"accepted":true}]`
+	if err != nil || string(data) != expected {
+		t.Errorf("json.Marshal gives (%s, %v); want %s", data, err, expected)
+	}
+
+	source, err := FormatGoTests("commitTests", cases)
+	expected = "var commitTests = []struct {\n" +
+		"\tsequence string\n" +
+		"\taccepted bool\n" +
+		"}{\n" +
+		"\t{\"abc\", true},\n" +
+		"}\n"
+	if err != nil || string(source) != expected {
+		t.Errorf("FormatGoTests gives (%s, %v); want %s", source, err, expected)
+	}
+}
@@ -0,0 +1,413 @@
+package lgraph
+
+import (
+	"container/heap"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrNegativeWeight is the error value returned by the searches that need
+// non-negative weights if they come across an edge with a negative weight.
+var ErrNegativeWeight = errors.New("negative edge weight")
+
+// ErrNegativeCycle is the error value returned by ShortestPathBellmanFord if
+// the target can be reached through a cycle of negative weight, so that there
+// is no cheapest path.
+var ErrNegativeCycle = errors.New("negative cycle")
+
+// Path is a path through a weighted graph.
+type Path struct {
+	// Nodes are the nodes on the path, from the source to the target.
+	Nodes []node
+	// Sequence is the sequence of labels read along the path. Epsilon edges
+	// read no label, and a set-labeled edge reads the smallest rune of its set.
+	Sequence []rune
+	// Weight is the sum of the weights of the edges on the path.
+	Weight int
+}
+
+// step is the edge taken from a node, index being its position among the
+// outgoing edges of the node.
+type step struct {
+	from  node
+	index int
+	edge  edge
+}
+
+func makePath(s node, steps []step) Path {
+	path := Path{Nodes: []node{s}, Sequence: []rune{}}
+	for _, st := range steps {
+		path.Nodes = append(path.Nodes, st.edge.destination)
+		if !st.edge.epsilon {
+			path.Sequence = append(path.Sequence, st.edge.accepts()[0].lo)
+		}
+		path.Weight += st.edge.weight
+	}
+	return path
+}
+
+// stepsKey encodes the edges taken by steps as a string usable as a map key.
+func stepsKey(steps []step) string {
+	var b strings.Builder
+	for _, st := range steps {
+		b.WriteString(strconv.FormatUint(uint64(st.from), 10))
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(st.index))
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// queueItem is an entry of a priorityQueue. Items are ordered by weight, then
+// by length, then first in first out, which keeps the searches deterministic.
+type queueItem struct {
+	weight int
+	length int
+	order  int
+	value  int
+}
+
+type priorityQueue []queueItem
+
+func (q priorityQueue) Len() int { return len(q) }
+
+func (q priorityQueue) Less(i, j int) bool {
+	if q[i].weight != q[j].weight {
+		return q[i].weight < q[j].weight
+	}
+	if q[i].length != q[j].length {
+		return q[i].length < q[j].length
+	}
+	return q[i].order < q[j].order
+}
+
+func (q priorityQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *priorityQueue) Push(x any) { *q = append(*q, x.(queueItem)) }
+
+func (q *priorityQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// dijkstra returns the steps of a cheapest path from s to t in g that avoids
+// the removed nodes and the removed edges, given by their stepsKey. Among the
+// cheapest paths it prefers one with fewer edges.
+func dijkstra(g LGraph, s, t node, removedNodes map[node]bool, removedEdges map[string]bool) ([]step, bool, error) {
+	if _, exists := g(s); !exists || removedNodes[s] {
+		return nil, false, nil
+	}
+	var nodes []node
+	index := map[node]int{}
+	dist := []int{}
+	length := []int{}
+	prev := []step{}
+	settled := []bool{}
+	add := func(n node) int {
+		if i, seen := index[n]; seen {
+			return i
+		}
+		index[n] = len(nodes)
+		nodes = append(nodes, n)
+		dist = append(dist, 0)
+		length = append(length, -1)
+		prev = append(prev, step{})
+		settled = append(settled, false)
+		return len(nodes) - 1
+	}
+
+	queue := &priorityQueue{}
+	order := 0
+	add(s)
+	length[0] = 0
+	heap.Push(queue, queueItem{0, 0, order, 0})
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(queueItem)
+		i := item.value
+		if settled[i] {
+			continue
+		}
+		settled[i] = true
+		if nodes[i] == t {
+			// follow the steps back to s
+			steps := make([]step, length[i])
+			for j := i; length[j] > 0; j = index[prev[j].from] {
+				steps[length[j]-1] = prev[j]
+			}
+			return steps, true, nil
+		}
+
+		edges, _ := g(nodes[i])
+		for k, edge := range edges {
+			if edge.weight < 0 {
+				return nil, false, ErrNegativeWeight
+			}
+			st := step{nodes[i], k, edge}
+			if removedNodes[edge.destination] || removedEdges[stepsKey([]step{st})] {
+				continue
+			}
+			j := add(edge.destination)
+			d, l := dist[i]+edge.weight, length[i]+1
+			if settled[j] || length[j] >= 0 && (d > dist[j] || d == dist[j] && l >= length[j]) {
+				continue
+			}
+			dist[j], length[j], prev[j] = d, l, st
+			order++
+			heap.Push(queue, queueItem{d, l, order, j})
+		}
+	}
+	return nil, false, nil
+}
+
+// ShortestPath returns (P, true, nil) if t is reachable from s in g, where P
+// is a cheapest path from s to t found with Dijkstra's algorithm; else it
+// returns (Path{}, false, nil). Among the cheapest paths it returns one with
+// the fewest edges. It returns ErrNegativeWeight if it comes across an edge
+// with a negative weight.
+func ShortestPath(g LGraph, s, t node) (Path, bool, error) {
+	steps, exists, err := dijkstra(g, s, t, nil, nil)
+	if !exists {
+		return Path{}, false, err
+	}
+	return makePath(s, steps), true, nil
+}
+
+// ShortestPathBellmanFord is like ShortestPath but uses the Bellman-Ford
+// algorithm, so edge weights may be negative. It returns ErrNegativeCycle if
+// t can be reached through a cycle of negative weight.
+func ShortestPathBellmanFord(g LGraph, s, t node) (Path, bool, error) {
+	if _, exists := g(s); !exists {
+		return Path{}, false, nil
+	}
+	nodes := Reachable(g, s)
+	dist := map[node]int{s: 0}
+	prev := map[node]step{}
+	relax := func() []node {
+		var relaxed []node
+		for _, n := range nodes {
+			d, reached := dist[n]
+			if !reached {
+				continue
+			}
+			edges, _ := g(n)
+			for k, edge := range edges {
+				old, seen := dist[edge.destination]
+				if !seen || d+edge.weight < old {
+					dist[edge.destination] = d + edge.weight
+					prev[edge.destination] = step{n, k, edge}
+					relaxed = append(relaxed, edge.destination)
+				}
+			}
+		}
+		return relaxed
+	}
+	for i := 1; i < len(nodes); i++ {
+		if len(relax()) == 0 {
+			break
+		}
+	}
+	if _, reached := dist[t]; !reached {
+		return Path{}, false, nil
+	}
+
+	// whatever can still be relaxed lies on or behind a negative cycle
+	behind := map[node]bool{}
+	queue := relax()
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if behind[n] {
+			continue
+		}
+		if n == t {
+			return Path{}, false, ErrNegativeCycle
+		}
+		behind[n] = true
+		edges, _ := g(n)
+		for _, edge := range edges {
+			queue = append(queue, edge.destination)
+		}
+	}
+
+	var steps []step
+	for n := t; n != s; n = prev[n].from {
+		steps = append(steps, prev[n])
+	}
+	for a, b := 0, len(steps)-1; a < b; a, b = a+1, b-1 {
+		steps[a], steps[b] = steps[b], steps[a]
+	}
+	return makePath(s, steps), true, nil
+}
+
+// KShortestPaths returns up to k cheapest paths from s to t in g that do not
+// repeat a node, cheapest first, found with Yen's algorithm. Paths of the same
+// weight are ordered by their number of edges. It returns ErrNegativeWeight if
+// it comes across an edge with a negative weight.
+func KShortestPaths(g LGraph, s, t node, k uint) ([]Path, error) {
+	if k == 0 {
+		return nil, nil
+	}
+	first, exists, err := dijkstra(g, s, t, nil, nil)
+	if !exists {
+		return nil, err
+	}
+	found := [][]step{first}
+	seen := map[string]bool{stepsKey(first): true}
+
+	var candidates [][]step
+	queue := &priorityQueue{}
+
+	for uint(len(found)) < k {
+		last := found[len(found)-1]
+		for i := range last {
+			spur := last[i].from
+			root := last[:i]
+			rootKey := stepsKey(root)
+
+			// leave out the edges that found paths with the same root take
+			// next, and the nodes of the root
+			removedEdges := map[string]bool{}
+			for _, p := range found {
+				if len(p) > i && stepsKey(p[:i]) == rootKey {
+					removedEdges[stepsKey(p[i:i+1])] = true
+				}
+			}
+			removedNodes := map[node]bool{}
+			for _, st := range root {
+				removedNodes[st.from] = true
+			}
+
+			spurSteps, exists, err := dijkstra(g, spur, t, removedNodes, removedEdges)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				continue
+			}
+			steps := append(append([]step(nil), root...), spurSteps...)
+			key := stepsKey(steps)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			weight := makePath(s, steps).Weight
+			heap.Push(queue, queueItem{weight, len(steps), len(candidates), len(candidates)})
+			candidates = append(candidates, steps)
+		}
+		if queue.Len() == 0 {
+			break
+		}
+		found = append(found, candidates[heap.Pop(queue).(queueItem).value])
+	}
+
+	paths := make([]Path, len(found))
+	for i, steps := range found {
+		paths[i] = makePath(s, steps)
+	}
+	return paths, nil
+}
+
+// CheapestSequence returns (S, w, true, nil) if there is a sequence S from
+// node s to node t in graph g1 that is not a sequence from s to t in graph g2,
+// where S is read along a cheapest such path of g1 and w is its weight; else it
+// returns (nil, 0, false, nil). Unlike FindSequence, S may have any length.
+// It returns ErrNegativeWeight if it comes across an edge of g1 with a
+// negative weight.
+func CheapestSequence(g1, g2 LGraph, s, t node) ([]rune, int, bool, error) {
+	return cheapestSequence(g1, g2, s, t, func(e edge) int { return e.weight })
+}
+
+// cheapestSequence implements CheapestSequence with cost giving the weight of
+// every edge of g1.
+func cheapestSequence(g1, g2 LGraph, s, t node, cost func(edge) int) ([]rune, int, bool, error) {
+	if _, exists := g1(s); !exists {
+		return nil, 0, false, nil
+	}
+	var start []node
+	if _, exists := g2(s); exists {
+		start = closure(g2, []node{s})
+	}
+
+	// Dijkstra over pairs of a node of g1 and the set of nodes of g2 that the
+	// same sequence reaches
+	type state struct {
+		current node
+		others  []node
+		prev    int
+		label   rune
+		labeled bool
+		weight  int
+		length  int
+		settled bool
+	}
+	var states []state
+	index := map[string]int{}
+	queue := &priorityQueue{}
+	order := 0
+	push := func(st state) {
+		key := strconv.FormatUint(uint64(st.current), 10) + "|" + nodeSetKey(st.others)
+		i, seen := index[key]
+		if seen {
+			old := states[i]
+			if old.settled || st.weight > old.weight || st.weight == old.weight && st.length >= old.length {
+				return
+			}
+			states[i] = st
+		} else {
+			i = len(states)
+			index[key] = i
+			states = append(states, st)
+		}
+		order++
+		heap.Push(queue, queueItem{st.weight, st.length, order, i})
+	}
+
+	push(state{current: s, others: start, prev: -1})
+	for queue.Len() > 0 {
+		i := heap.Pop(queue).(queueItem).value
+		if states[i].settled {
+			continue
+		}
+		states[i].settled = true
+		st := states[i]
+		if st.current == t && !containsNode(st.others, t) {
+			sequence := []rune{}
+			for j := i; j >= 0; j = states[j].prev {
+				if states[j].labeled {
+					sequence = append(sequence, states[j].label)
+				}
+			}
+			for a, b := 0, len(sequence)-1; a < b; a, b = a+1, b-1 {
+				sequence[a], sequence[b] = sequence[b], sequence[a]
+			}
+			return sequence, st.weight, true, nil
+		}
+
+		edges, _ := g1(st.current)
+		for _, edge := range edges {
+			weight := cost(edge)
+			if weight < 0 {
+				return nil, 0, false, ErrNegativeWeight
+			}
+			next := state{
+				current: edge.destination,
+				prev:    i,
+				weight:  st.weight + weight,
+				length:  st.length + 1,
+			}
+			if edge.epsilon {
+				next.others = st.others
+				push(next)
+				continue
+			}
+			for _, step := range splitLabels(g2, st.others, edge.accepts()) {
+				next.others, next.label, next.labeled = step.next, step.witness, true
+				push(next)
+			}
+		}
+	}
+	return nil, 0, false, nil
+}
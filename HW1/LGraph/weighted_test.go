@@ -0,0 +1,164 @@
+package lgraph
+
+import (
+	"reflect"
+	"testing"
+)
+
+/*
+Graph GW:
+
+	0 --a/1--> 1
+	0 --b/4--> 2
+	1 --c/2--> 2
+	1 --d/6--> 3
+	1 --ε/5--> 3
+	2 --e/3--> 3
+*/
+var gW = mkGraph(map[node][]edge{
+	0: {{destination: 1, label: 'a', weight: 1}, {destination: 2, label: 'b', weight: 4}},
+	1: {{destination: 2, label: 'c', weight: 2}, {destination: 3, label: 'd', weight: 6}, {destination: 3, epsilon: true, weight: 5}},
+	2: {{destination: 3, label: 'e', weight: 3}},
+	3: {},
+})
+
+// GW without the epsilon and d edges, and with a direct 0 --a--> 3.
+var gW2 = mkGraph(map[node][]edge{
+	0: {{destination: 1, label: 'a', weight: 1}, {destination: 2, label: 'b', weight: 4}, {destination: 3, label: 'a'}},
+	1: {{destination: 2, label: 'c', weight: 2}},
+	2: {{destination: 3, label: 'e', weight: 3}},
+	3: {},
+})
+
+var gNegative = mkGraph(map[node][]edge{
+	0: {{destination: 1, label: 'a', weight: 4}, {destination: 2, label: 'b', weight: 1}},
+	1: {{destination: 3, label: 'd', weight: 1}},
+	2: {{destination: 1, label: 'c', weight: -2}},
+	3: {},
+})
+
+var gNegativeCycle = mkGraph(map[node][]edge{
+	0: {{destination: 1, label: 'a', weight: 1}, {destination: 4, label: 'e', weight: 1}},
+	1: {{destination: 2, label: 'b', weight: -2}, {destination: 3, label: 'd', weight: 1}},
+	2: {{destination: 1, label: 'c', weight: 1}},
+	3: {},
+	4: {},
+})
+
+func TestShortestPath(t *testing.T) {
+	tests := []struct {
+		graph          LGraph
+		source, target node
+		expected       Path
+		expectedExists bool
+		expectedErr    error
+	}{
+		{gW, 0, 3, Path{[]node{0, 1, 3}, []rune{'a'}, 6}, true, nil},
+		{gW, 0, 2, Path{[]node{0, 1, 2}, []rune{'a', 'c'}, 3}, true, nil},
+		{gW, 2, 2, Path{[]node{2}, []rune{}, 0}, true, nil},
+		{gW, 3, 0, Path{}, false, nil},
+		{gW, 17, 0, Path{}, false, nil},
+		{g1, 0, 6, Path{[]node{0, 1, 3, 7, 6}, []rune{'b', 'f', 'j', 'l'}, 0}, true, nil},
+		{g4, 0, 4, Path{[]node{0, 1, 2, 3, 4}, []rune{'a', 'x'}, 0}, true, nil},
+		{gNegative, 0, 3, Path{}, false, ErrNegativeWeight},
+	}
+	for i, test := range tests {
+		actual, exists, err := ShortestPath(test.graph, test.source, test.target)
+		if err != test.expectedErr || exists != test.expectedExists || !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("#%d: ShortestPath(%d, %d)=(%v, %t, %v); want (%v, %t, %v)", i,
+				test.source, test.target, actual, exists, err,
+				test.expected, test.expectedExists, test.expectedErr)
+		}
+	}
+}
+
+func TestShortestPathBellmanFord(t *testing.T) {
+	tests := []struct {
+		graph          LGraph
+		source, target node
+		expected       Path
+		expectedExists bool
+		expectedErr    error
+	}{
+		{gW, 0, 2, Path{[]node{0, 1, 2}, []rune{'a', 'c'}, 3}, true, nil},
+		{gW, 2, 2, Path{[]node{2}, []rune{}, 0}, true, nil},
+		{gW, 3, 0, Path{}, false, nil},
+		{gW, 17, 17, Path{}, false, nil},
+		{gNegative, 0, 3, Path{[]node{0, 2, 1, 3}, []rune{'b', 'c', 'd'}, 0}, true, nil},
+		{gNegativeCycle, 0, 3, Path{}, false, ErrNegativeCycle},
+		{gNegativeCycle, 0, 1, Path{}, false, ErrNegativeCycle},
+		{gNegativeCycle, 0, 4, Path{[]node{0, 4}, []rune{'e'}, 1}, true, nil},
+	}
+	for i, test := range tests {
+		actual, exists, err := ShortestPathBellmanFord(test.graph, test.source, test.target)
+		if err != test.expectedErr || exists != test.expectedExists || !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("#%d: ShortestPathBellmanFord(%d, %d)=(%v, %t, %v); want (%v, %t, %v)", i,
+				test.source, test.target, actual, exists, err,
+				test.expected, test.expectedExists, test.expectedErr)
+		}
+	}
+}
+
+func TestKShortestPaths(t *testing.T) {
+	paths, err := KShortestPaths(gW, 0, 3, 10)
+	expected := []Path{
+		{[]node{0, 1, 3}, []rune{'a'}, 6},
+		{[]node{0, 1, 2, 3}, []rune{'a', 'c', 'e'}, 6},
+		{[]node{0, 2, 3}, []rune{'b', 'e'}, 7},
+		{[]node{0, 1, 3}, []rune{'a', 'd'}, 7},
+	}
+	if err != nil || !reflect.DeepEqual(paths, expected) {
+		t.Errorf("KShortestPaths(gW, 0, 3, 10)=(%v, %v); want (%v, nil)", paths, err, expected)
+	}
+
+	paths, err = KShortestPaths(gW, 0, 3, 2)
+	if err != nil || !reflect.DeepEqual(paths, expected[:2]) {
+		t.Errorf("KShortestPaths(gW, 0, 3, 2)=(%v, %v); want (%v, nil)", paths, err, expected[:2])
+	}
+
+	// g2 has infinitely many paths from 0 to 6 but only one without a
+	// repeated node
+	paths, err = KShortestPaths(g2, 0, 6, 10)
+	if err != nil || len(paths) != 1 || string(paths[0].Sequence) != "bfjl" {
+		t.Errorf("KShortestPaths(g2, 0, 6, 10)=(%v, %v)", paths, err)
+	}
+
+	for _, k := range []uint{0, 3} {
+		if paths, err := KShortestPaths(gW, 3, 0, k); paths != nil || err != nil {
+			t.Errorf("KShortestPaths(gW, 3, 0, %d)=(%v, %v); want (nil, nil)", k, paths, err)
+		}
+	}
+	if _, err := KShortestPaths(gNegative, 0, 3, 2); err != ErrNegativeWeight {
+		t.Errorf("KShortestPaths(gNegative) gives %v; want %v", err, ErrNegativeWeight)
+	}
+}
+
+func TestCheapestSequence(t *testing.T) {
+	tests := []struct {
+		graph1, graph2         LGraph
+		source, target         node
+		expectedSequence       []rune
+		expectedWeight         int
+		expectedSequenceExists bool
+		expectedErr            error
+	}{
+		{gW, gW2, 0, 3, []rune{'a', 'd'}, 7, true, nil},
+		{gW2, gW, 0, 3, nil, 0, false, nil},
+		{gW, g1, 0, 3, []rune{'a'}, 6, true, nil},
+		{g1, g2, 0, 6, []rune{'b', 'f', 'k', 'm', 'l'}, 0, true, nil},
+		{g1, g2, 0, 2, nil, 0, false, nil},
+		{g1, g2, 7, 7, []rune{}, 0, true, nil},
+		{g4, g5, 0, 4, []rune{'n', 'x'}, 0, true, nil},
+		{g1, g2, 17, 0, nil, 0, false, nil},
+		{gNegative, g1, 0, 3, nil, 0, false, ErrNegativeWeight},
+	}
+	for i, test := range tests {
+		sequence, weight, exists, err := CheapestSequence(test.graph1, test.graph2, test.source, test.target)
+		if err != test.expectedErr || exists != test.expectedSequenceExists || weight != test.expectedWeight ||
+			!reflect.DeepEqual(sequence, test.expectedSequence) {
+			t.Errorf("#%d: CheapestSequence(%d, %d)=(%q, %d, %t, %v); want (%q, %d, %t, %v)", i,
+				test.source, test.target, sequence, weight, exists, err,
+				test.expectedSequence, test.expectedWeight, test.expectedSequenceExists, test.expectedErr)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package disjointset
+
+// AggregateDisjointSet is a DisjointSet that keeps data of type A for every
+// set, such as a sum, a minimum or the list of members, and combines the data
+// of two sets when they merge.
+type AggregateDisjointSet[A any] struct {
+	set     *disjointSet
+	initial func(x int) A
+	merge   func(a, b A) A
+	// data holds the data of the sets whose representative is not in a set
+	// of its own
+	data map[int]A
+}
+
+// NewAggregateDisjointSet returns an empty AggregateDisjointSet. The data of
+// the set of x alone is initial(x), and whenever UnionSet(x, y) merges two
+// sets, the data of the merged set becomes merge(a, b), where a is the data
+// of the set of x and b that of y.
+func NewAggregateDisjointSet[A any](initial func(x int) A, merge func(a, b A) A) *AggregateDisjointSet[A] {
+	return &AggregateDisjointSet[A]{
+		set:     &disjointSet{map[int]int{}, map[int]int{}},
+		initial: initial,
+		merge:   merge,
+		data:    map[int]A{},
+	}
+}
+
+func (s *AggregateDisjointSet[A]) FindSet(x int) int {
+	return s.set.FindSet(x)
+}
+
+// UnionSet merges the sets containing x and y, and returns the representative
+// of the merged set. It calls merge only if x and y were in different sets.
+func (s *AggregateDisjointSet[A]) UnionSet(x, y int) int {
+	rx, ry := s.set.FindSet(x), s.set.FindSet(y)
+	if rx == ry {
+		return rx
+	}
+	a, b := s.aggregate(rx), s.aggregate(ry)
+	delete(s.data, rx)
+	delete(s.data, ry)
+	root := s.set.UnionSet(rx, ry)
+	s.data[root] = s.merge(a, b)
+	return root
+}
+
+// Aggregate returns the data of the set that x belongs to.
+func (s *AggregateDisjointSet[A]) Aggregate(x int) A {
+	return s.aggregate(s.set.FindSet(x))
+}
+
+func (s *AggregateDisjointSet[A]) aggregate(root int) A {
+	if a, ok := s.data[root]; ok {
+		return a
+	}
+	return s.initial(root)
+}
+
+func (s *AggregateDisjointSet[A]) elements() []int {
+	return s.set.elements()
+}
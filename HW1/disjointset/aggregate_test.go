@@ -0,0 +1,86 @@
+package disjointset
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestAggregateDisjointSet(t *testing.T) {
+	for testNo, test := range disjointSetTests {
+		s := NewAggregateDisjointSet(func(int) struct{} { return struct{}{} },
+			func(a, b struct{}) struct{} { return a })
+		for _, unionSetStep := range test.unionSetSteps {
+			s.UnionSet(unionSetStep.a, unionSetStep.b)
+		}
+		for _, findSetCheck := range test.findSetChecks {
+			if actual := s.FindSet(findSetCheck.a) == s.FindSet(findSetCheck.b); actual != findSetCheck.expected {
+				t.Errorf("In test %d, FindSet(%d) == FindSet(%d) gives %t, expected %t",
+					testNo+1, findSetCheck.a, findSetCheck.b, actual, findSetCheck.expected)
+			}
+		}
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	type stats struct{ sum, min, max, count int }
+	merges := 0
+	s := NewAggregateDisjointSet(
+		func(x int) stats { return stats{x, x, x, 1} },
+		func(a, b stats) stats {
+			merges++
+			return stats{a.sum + b.sum, min(a.min, b.min), max(a.max, b.max), a.count + b.count}
+		})
+	s.UnionSet(3, 7)
+	s.UnionSet(-2, 5)
+	s.UnionSet(7, 3)
+	s.UnionSet(5, 7)
+	s.UnionSet(10, 10)
+	if merges != 3 {
+		t.Errorf("merge was called %d times; want 3", merges)
+	}
+
+	tests := []struct {
+		x    int
+		want stats
+	}{
+		{3, stats{13, -2, 7, 4}},
+		{-2, stats{13, -2, 7, 4}},
+		{10, stats{10, 10, 10, 1}},
+		{11, stats{11, 11, 11, 1}},
+	}
+	for i, test := range tests {
+		if got := s.Aggregate(test.x); got != test.want {
+			t.Errorf("#%d: Aggregate(%d)=%+v; want %+v", i, test.x, got, test.want)
+		}
+	}
+	if len(s.data) != 1 {
+		t.Errorf("Data is kept for %d sets; want 1", len(s.data))
+	}
+}
+
+// merge gets the data of the set of the first argument of UnionSet first,
+// and member lists agree with Sets.
+func TestAggregateMembers(t *testing.T) {
+	s := NewAggregateDisjointSet(
+		func(x int) []int { return []int{x} },
+		func(a, b []int) []int { return append(append([]int(nil), a...), b...) })
+	s.UnionSet(1, 2)
+	s.UnionSet(3, 1)
+	if got := s.Aggregate(2); !reflect.DeepEqual(got, []int{3, 1, 2}) {
+		t.Errorf("Aggregate(2)=%v; want [3 1 2]", got)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		s.UnionSet(rng.Intn(100), rng.Intn(100))
+	}
+	for _, set := range mustSets(t, s) {
+		members := append([]int(nil), s.Aggregate(set[0])...)
+		sort.Ints(members)
+		if !reflect.DeepEqual(members, set) {
+			t.Errorf("Aggregate(%d) has members %v; want %v", set[0], members, set)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package disjointset
+
+import "sync/atomic"
+
+// ConcurrentDisjointSet is a lock-free disjoint set over the elements 0 to
+// n-1 that is safe for concurrent use by many goroutines, after Anderson and
+// Woll. Roots are linked with compare-and-swap, always under the root of
+// higher priority, and finds halve their paths with compare-and-swap too, so
+// parents only ever move up the tree and no goroutine waits for another one.
+// The priorities are a fixed hash of the elements, which keeps the trees
+// shallow in expectation without storing ranks.
+type ConcurrentDisjointSet struct {
+	parent []atomic.Int64
+}
+
+// NewConcurrentDisjointSet returns a ConcurrentDisjointSet of the n elements
+// 0 to n-1, each in a set of its own.
+func NewConcurrentDisjointSet(n int) *ConcurrentDisjointSet {
+	s := &ConcurrentDisjointSet{make([]atomic.Int64, n)}
+	for i := range s.parent {
+		s.parent[i].Store(int64(i))
+	}
+	return s
+}
+
+// Len returns the number of elements of s.
+func (s *ConcurrentDisjointSet) Len() int {
+	return len(s.parent)
+}
+
+// FindSet returns the representative of the set that x belongs to. While
+// other goroutines unite sets, the representative it returns may stop being
+// one as soon as it returns; use SameSet to compare two elements. It panics
+// if x is not between 0 and n-1.
+func (s *ConcurrentDisjointSet) FindSet(x int) int {
+	s.check(x)
+	for {
+		p := int(s.parent[x].Load())
+		if p == x {
+			return x
+		}
+		gp := s.parent[p].Load()
+		if int(gp) != p {
+			// path halving: if another goroutine moved x first, its new
+			// parent is at least as high up
+			s.parent[x].CompareAndSwap(int64(p), gp)
+		}
+		x = p
+	}
+}
+
+// UnionSet merges the sets containing x and y, and returns the representative
+// of the merged set. It panics if x or y is not between 0 and n-1.
+func (s *ConcurrentDisjointSet) UnionSet(x, y int) int {
+	for {
+		x, y = s.FindSet(x), s.FindSet(y)
+		if x == y {
+			return x
+		}
+		if less(x, y) {
+			x, y = y, x
+		}
+		// fails if another goroutine linked y meanwhile; then find again
+		if s.parent[y].CompareAndSwap(int64(y), int64(x)) {
+			return x
+		}
+	}
+}
+
+// SameSet reports whether x and y are in the same set. Unlike comparing two
+// calls of FindSet, the answer is exact at some moment during the call even
+// while other goroutines unite sets.
+func (s *ConcurrentDisjointSet) SameSet(x, y int) bool {
+	for {
+		x, y = s.FindSet(x), s.FindSet(y)
+		if x == y {
+			return true
+		}
+		// x was a root before and after y's root was found, so at that moment
+		// both were roots of different sets
+		if int(s.parent[x].Load()) == x {
+			return false
+		}
+	}
+}
+
+func (s *ConcurrentDisjointSet) check(x int) {
+	if x < 0 || x >= len(s.parent) {
+		panic("disjointset: element out of range")
+	}
+}
+
+// less orders the elements by a hash, and by value if their hashes are equal.
+func less(x, y int) bool {
+	hx, hy := priority(x), priority(y)
+	return hx < hy || hx == hy && x < y
+}
+
+// priority is the splitmix64 finalizer.
+func priority(x int) uint64 {
+	z := uint64(x) + 0x9e3779b97f4a7c15
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
@@ -0,0 +1,137 @@
+package disjointset
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentDisjointSet(t *testing.T) {
+	for testNo, test := range disjointSetTests {
+		s := NewConcurrentDisjointSet(100)
+		for _, unionSetStep := range test.unionSetSteps {
+			s.UnionSet(unionSetStep.a, unionSetStep.b)
+		}
+		for _, findSetCheck := range test.findSetChecks {
+			if actual := s.FindSet(findSetCheck.a) == s.FindSet(findSetCheck.b); actual != findSetCheck.expected {
+				t.Errorf("In test %d, FindSet(%d) == FindSet(%d) gives %t, expected %t",
+					testNo+1, findSetCheck.a, findSetCheck.b, actual, findSetCheck.expected)
+			}
+			if actual := s.SameSet(findSetCheck.a, findSetCheck.b); actual != findSetCheck.expected {
+				t.Errorf("In test %d, SameSet(%d, %d) gives %t, expected %t",
+					testNo+1, findSetCheck.a, findSetCheck.b, actual, findSetCheck.expected)
+			}
+		}
+	}
+}
+
+func TestConcurrentOutOfRange(t *testing.T) {
+	s := NewConcurrentDisjointSet(3)
+	for _, x := range []int{-1, 3} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("FindSet(%d) of 3 elements did not panic", x)
+				}
+			}()
+			s.FindSet(x)
+		}()
+	}
+}
+
+// Goroutines uniting random pairs at the same time end with the same sets as
+// a fresh set built from all the pairs.
+func TestConcurrentUnions(t *testing.T) {
+	const n, workers, perWorker = 1000, 8, 300
+	rng := rand.New(rand.NewSource(1))
+	pairs := make([][2]int, workers*perWorker)
+	for i := range pairs {
+		pairs[i] = [2]int{rng.Intn(n), rng.Intn(n)}
+	}
+
+	s := NewConcurrentDisjointSet(n)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(pairs [][2]int) {
+			defer wg.Done()
+			for i, p := range pairs {
+				s.UnionSet(p[0], p[1])
+				if !s.SameSet(p[0], p[1]) {
+					t.Errorf("SameSet(%d, %d) is false after uniting them", p[0], p[1])
+				}
+				s.FindSet(pairs[len(pairs)-1-i][0])
+			}
+		}(pairs[w*perWorker : (w+1)*perWorker])
+	}
+	wg.Wait()
+
+	fresh := NewDisjointSet()
+	for _, p := range pairs {
+		fresh.UnionSet(p[0], p[1])
+	}
+	for i := 0; i < 2000; i++ {
+		a, b := rng.Intn(n), rng.Intn(n)
+		if s.SameSet(a, b) != (fresh.FindSet(a) == fresh.FindSet(b)) {
+			t.Fatalf("%d and %d disagree with a fresh set", a, b)
+		}
+	}
+}
+
+// lockedDisjointSet is the sequential DisjointSet behind a mutex, to compare
+// ConcurrentDisjointSet with.
+type lockedDisjointSet struct {
+	mu  sync.Mutex
+	set DisjointSet
+}
+
+func (s *lockedDisjointSet) FindSet(x int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set.FindSet(x)
+}
+
+func (s *lockedDisjointSet) UnionSet(x, y int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set.UnionSet(x, y)
+}
+
+// labelGrid unites the neighbouring pixels of a side×side image that have
+// the same color, the way connected-component labelling does, with the rows
+// shared out among parallel goroutines. Every operation labels one row.
+func labelGrid(b *testing.B, s DisjointSet, side int) {
+	rng := rand.New(rand.NewSource(1))
+	color := make([]bool, side*side)
+	for i := range color {
+		color[i] = rng.Intn(3) > 0
+	}
+	var row sync.Mutex
+	next := 0
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			row.Lock()
+			r := next % side
+			next++
+			row.Unlock()
+			for c := 0; c < side; c++ {
+				p := r*side + c
+				if c+1 < side && color[p] == color[p+1] {
+					s.UnionSet(p, p+1)
+				}
+				if r+1 < side && color[p] == color[p+side] {
+					s.UnionSet(p, p+side)
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkConcurrentDisjointSet(b *testing.B) {
+	labelGrid(b, NewConcurrentDisjointSet(256*256), 256)
+}
+
+func BenchmarkLockedDisjointSet(b *testing.B) {
+	labelGrid(b, &lockedDisjointSet{set: NewDisjointSet()}, 256)
+}
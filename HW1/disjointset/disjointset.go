@@ -11,10 +11,50 @@ type DisjointSet interface {
 	FindSet(int) int
 }
 
-// TODO: implement a type that satisfies the DisjointSet interface.
+// disjointSet is a forest of parent pointers with union by rank and path
+// compression. Elements that were never united with another one are roots of
+// their own and are not stored.
+type disjointSet struct {
+	parent map[int]int
+	rank   map[int]int
+}
 
 // NewDisjointSet creates a struct of a type that satisfies the DisjointSet interface.
+// It also satisfies encoding.BinaryMarshaler, encoding.BinaryUnmarshaler,
+// json.Marshaler and json.Unmarshaler.
 func NewDisjointSet() DisjointSet {
-	panic("TODO: implement this!")
-	// disjoint
+	return &disjointSet{map[int]int{}, map[int]int{}}
+}
+
+func (s *disjointSet) FindSet(x int) int {
+	root := x
+	for {
+		parent, ok := s.parent[root]
+		if !ok {
+			break
+		}
+		root = parent
+	}
+	// point every element on the way straight at the root
+	for x != root {
+		next := s.parent[x]
+		s.parent[x] = root
+		x = next
+	}
+	return root
+}
+
+func (s *disjointSet) UnionSet(x, y int) int {
+	x, y = s.FindSet(x), s.FindSet(y)
+	if x == y {
+		return x
+	}
+	if s.rank[x] < s.rank[y] {
+		x, y = y, x
+	}
+	s.parent[y] = x
+	if s.rank[x] == s.rank[y] {
+		s.rank[x]++
+	}
+	return x
 }
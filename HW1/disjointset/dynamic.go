@@ -0,0 +1,125 @@
+package disjointset
+
+import "errors"
+
+// ErrNoEdge is the error value returned by DynamicConnectivity.RemoveEdge if
+// the edge is not in the graph.
+var ErrNoEdge = errors.New("no such edge")
+
+// DynamicConnectivity answers connectivity queries on an undirected graph
+// whose edges come and go. It works offline: it records the edge additions,
+// removals and queries in order, and Answers then answers all queries at
+// once. Every edge is present for a contiguous range of queries; the ranges
+// are spread over a segment tree of the queries, and a depth-first walk of
+// the tree unites the edges of a node on the way down and rolls them back on
+// the way up, all in O((m + q) log q log n) time for m edges and q queries.
+type DynamicConnectivity struct {
+	// open maps an edge to the query indices at which its copies were
+	// added and not removed yet
+	open    map[[2]int][]int
+	spans   []edgeSpan
+	queries [][2]int
+}
+
+// edgeSpan is an edge that is present for the queries from up to but not
+// including to.
+type edgeSpan struct {
+	edge     [2]int
+	from, to int
+}
+
+// NewDynamicConnectivity returns a DynamicConnectivity of a graph without
+// edges.
+func NewDynamicConnectivity() *DynamicConnectivity {
+	return &DynamicConnectivity{open: map[[2]int][]int{}}
+}
+
+// edgeKey returns the edge between u and v whichever way round it is given.
+func edgeKey(u, v int) [2]int {
+	if u > v {
+		u, v = v, u
+	}
+	return [2]int{u, v}
+}
+
+// AddEdge adds an edge between u and v. Adding an edge that is present
+// already adds a parallel copy, which a RemoveEdge must remove too.
+func (c *DynamicConnectivity) AddEdge(u, v int) {
+	e := edgeKey(u, v)
+	c.open[e] = append(c.open[e], len(c.queries))
+}
+
+// RemoveEdge removes a copy of the edge between u and v. It returns
+// ErrNoEdge if there is none.
+func (c *DynamicConnectivity) RemoveEdge(u, v int) error {
+	e := edgeKey(u, v)
+	starts := c.open[e]
+	if len(starts) == 0 {
+		return ErrNoEdge
+	}
+	c.spans = append(c.spans, edgeSpan{e, starts[len(starts)-1], len(c.queries)})
+	if len(starts) == 1 {
+		delete(c.open, e)
+	} else {
+		c.open[e] = starts[:len(starts)-1]
+	}
+	return nil
+}
+
+// Connected records the query whether u and v are connected by the edges
+// present now, and returns its index among the answers.
+func (c *DynamicConnectivity) Connected(u, v int) int {
+	c.queries = append(c.queries, [2]int{u, v})
+	return len(c.queries) - 1
+}
+
+// Answers returns the answers to all the queries recorded so far, in order.
+func (c *DynamicConnectivity) Answers() []bool {
+	q := len(c.queries)
+	answers := make([]bool, q)
+	if q == 0 {
+		return answers
+	}
+	tree := make([][][2]int, 4*q)
+	var insert func(node, lo, hi int, span edgeSpan)
+	insert = func(node, lo, hi int, span edgeSpan) {
+		if span.to <= lo || hi <= span.from {
+			return
+		}
+		if span.from <= lo && hi <= span.to {
+			tree[node] = append(tree[node], span.edge)
+			return
+		}
+		mid := (lo + hi) / 2
+		insert(2*node, lo, mid, span)
+		insert(2*node+1, mid, hi, span)
+	}
+	for _, span := range c.spans {
+		insert(1, 0, q, span)
+	}
+	for e, starts := range c.open {
+		for _, from := range starts {
+			insert(1, 0, q, edgeSpan{e, from, q})
+		}
+	}
+
+	s := NewRollbackDisjointSet()
+	var walk func(node, lo, hi int)
+	walk = func(node, lo, hi int) {
+		checkpoint := s.Checkpoint()
+		for _, e := range tree[node] {
+			s.UnionSet(e[0], e[1])
+		}
+		if hi-lo == 1 {
+			query := c.queries[lo]
+			answers[lo] = s.FindSet(query[0]) == s.FindSet(query[1])
+		} else {
+			mid := (lo + hi) / 2
+			walk(2*node, lo, mid)
+			walk(2*node+1, mid, hi)
+		}
+		s.Rollback(checkpoint)
+	}
+	walk(1, 0, q)
+	return answers
+}
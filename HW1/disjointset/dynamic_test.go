@@ -0,0 +1,94 @@
+package disjointset
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDynamicConnectivity(t *testing.T) {
+	c := NewDynamicConnectivity()
+	var want []bool
+	query := func(u, v int, connected bool) {
+		c.Connected(u, v)
+		want = append(want, connected)
+	}
+
+	query(1, 1, true)
+	query(1, 2, false)
+	c.AddEdge(1, 2)
+	c.AddEdge(2, 3)
+	query(1, 3, true)
+	c.AddEdge(3, 1)
+	if err := c.RemoveEdge(2, 1); err != nil {
+		t.Errorf("RemoveEdge(2, 1)=%v; want nil", err)
+	}
+	query(1, 2, true) // through 3
+	c.RemoveEdge(1, 3)
+	query(1, 2, false)
+	query(2, 3, true)
+	c.AddEdge(4, 5)
+	c.AddEdge(4, 5)
+	c.RemoveEdge(5, 4)
+	query(4, 5, true) // a parallel copy is left
+	c.RemoveEdge(4, 5)
+	query(4, 5, false)
+	if err := c.RemoveEdge(4, 5); err != ErrNoEdge {
+		t.Errorf("RemoveEdge of a removed edge gives %v; want ErrNoEdge", err)
+	}
+	if err := c.RemoveEdge(7, 8); err != ErrNoEdge {
+		t.Errorf("RemoveEdge of a missing edge gives %v; want ErrNoEdge", err)
+	}
+	if i := c.Connected(3, 2); i != len(want) {
+		t.Errorf("Connected gives index %d; want %d", i, len(want))
+	}
+	want = append(want, true)
+
+	answers := c.Answers()
+	if len(answers) != len(want) {
+		t.Fatalf("Answers() has %d answers; want %d", len(answers), len(want))
+	}
+	for i := range want {
+		if answers[i] != want[i] {
+			t.Errorf("Answer %d is %t; want %t", i, answers[i], want[i])
+		}
+	}
+	if answers := NewDynamicConnectivity().Answers(); len(answers) != 0 {
+		t.Errorf("Answers() without queries=%v; want none", answers)
+	}
+}
+
+// Every answer agrees with a fresh set built from the edges present at the
+// time of the query.
+func TestDynamicConnectivityRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	c := NewDynamicConnectivity()
+	var edges [][2]int
+	var want []bool
+	for step := 0; step < 1000; step++ {
+		switch r := rng.Intn(10); {
+		case r < 3:
+			u, v := rng.Intn(20), rng.Intn(20)
+			c.AddEdge(u, v)
+			edges = append(edges, [2]int{u, v})
+		case r < 5 && len(edges) > 0:
+			i := rng.Intn(len(edges))
+			if err := c.RemoveEdge(edges[i][1], edges[i][0]); err != nil {
+				t.Fatalf("Step %d: RemoveEdge(%d, %d)=%v; want nil", step, edges[i][1], edges[i][0], err)
+			}
+			edges = append(edges[:i], edges[i+1:]...)
+		default:
+			u, v := rng.Intn(20), rng.Intn(20)
+			c.Connected(u, v)
+			fresh := NewDisjointSet()
+			for _, e := range edges {
+				fresh.UnionSet(e[0], e[1])
+			}
+			want = append(want, fresh.FindSet(u) == fresh.FindSet(v))
+		}
+	}
+	for i, answer := range c.Answers() {
+		if answer != want[i] {
+			t.Errorf("Answer %d is %t; want %t", i, answer, want[i])
+		}
+	}
+}
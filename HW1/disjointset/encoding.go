@@ -0,0 +1,314 @@
+package disjointset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// ErrCorrupt is the error value returned when decoding data that is not an
+// encoding of a disjoint set.
+var ErrCorrupt = errors.New("corrupt disjoint set encoding")
+
+// ErrNotListable is the error value returned by Sets, Equal and WriteBinary
+// for a DisjointSet from outside this package, whose elements they cannot
+// list.
+var ErrNotListable = errors.New("cannot list the elements of the disjoint set")
+
+// binaryVersion is the first byte of the binary encoding.
+const binaryVersion = 1
+
+// lister is implemented by the disjoint sets of this package, which can list
+// every element they store. Elements that are in a set of their own may be
+// left out.
+type lister interface {
+	elements() []int
+}
+
+func (s *disjointSet) elements() []int {
+	return mapElements(s.parent, s.rank)
+}
+
+func (s *RollbackDisjointSet) elements() []int {
+	return mapElements(s.parent, s.rank)
+}
+
+func (s *ConcurrentDisjointSet) elements() []int {
+	elements := make([]int, len(s.parent))
+	for i := range elements {
+		elements[i] = i
+	}
+	return elements
+}
+
+// mapElements returns the elements with a parent, and the roots, whose rank
+// is above zero once they have a child.
+func mapElements(parent, rank map[int]int) []int {
+	var elements []int
+	for x := range parent {
+		elements = append(elements, x)
+	}
+	for x := range rank {
+		elements = append(elements, x)
+	}
+	return elements
+}
+
+// Sets returns the partition of s into sets with more than one element,
+// canonically: every set sorted, and the sets sorted by their smallest
+// elements. Two disjoint sets that unite the same elements have the same
+// Sets whichever representatives they chose. It returns ErrNotListable if s
+// is not one of the disjoint sets of this package.
+func Sets(s DisjointSet) ([][]int, error) {
+	l, ok := s.(lister)
+	if !ok {
+		return nil, fmt.Errorf("%T: %w", s, ErrNotListable)
+	}
+	return sets(s, l), nil
+}
+
+// sets is Sets for s listed by l.
+func sets(s DisjointSet, l lister) [][]int {
+	byRoot := map[int][]int{}
+	seen := map[int]bool{}
+	for _, x := range l.elements() {
+		if !seen[x] {
+			seen[x] = true
+			root := s.FindSet(x)
+			byRoot[root] = append(byRoot[root], x)
+		}
+	}
+	sets := [][]int{}
+	for _, set := range byRoot {
+		if len(set) > 1 {
+			sort.Ints(set)
+			sets = append(sets, set)
+		}
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i][0] < sets[j][0] })
+	return sets
+}
+
+// Equal reports whether a and b partition the elements the same way,
+// regardless of their representatives. It returns ErrNotListable if a or b is
+// not one of the disjoint sets of this package.
+func Equal(a, b DisjointSet) (bool, error) {
+	sa, err := Sets(a)
+	if err != nil {
+		return false, err
+	}
+	sb, err := Sets(b)
+	if err != nil {
+		return false, err
+	}
+	if len(sa) != len(sb) {
+		return false, nil
+	}
+	for i := range sa {
+		if len(sa[i]) != len(sb[i]) {
+			return false, nil
+		}
+		for j := range sa[i] {
+			if sa[i][j] != sb[i][j] {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// add puts the elements of set, which must not be in s yet, into one set
+// whose representative is the smallest of them. It returns ErrCorrupt if an
+// element is in s already or twice in set.
+func (s *disjointSet) add(set []int) error {
+	if len(set) == 0 {
+		return nil
+	}
+	root := set[0]
+	for _, x := range set {
+		root = min(root, x)
+	}
+	rootSeen := false
+	for _, x := range set {
+		if _, ok := s.parent[x]; ok || s.rank[x] > 0 || x == root && rootSeen {
+			return fmt.Errorf("%w: %d is in two sets", ErrCorrupt, x)
+		}
+		if x == root {
+			rootSeen = true
+		} else {
+			s.parent[x] = root
+		}
+	}
+	if len(set) > 1 {
+		s.rank[root] = 1
+	}
+	return nil
+}
+
+// WriteBinary writes the partition of s to w in the binary encoding: a
+// version byte, the number of sets, and for every set of Sets(s) its size,
+// its smallest element as a varint and the gaps to the next elements as
+// uvarints. It returns ErrNotListable, writing nothing, if s is not one of the
+// disjoint sets of this package.
+func WriteBinary(w io.Writer, s DisjointSet) error {
+	sets, err := Sets(s)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	buf := make([]byte, binary.MaxVarintLen64)
+	put := func(n int) { bw.Write(buf[:n]) }
+	bw.WriteByte(binaryVersion)
+	put(binary.PutUvarint(buf, uint64(len(sets))))
+	for _, set := range sets {
+		put(binary.PutUvarint(buf, uint64(len(set))))
+		put(binary.PutVarint(buf, int64(set[0])))
+		for i := 1; i < len(set); i++ {
+			put(binary.PutUvarint(buf, uint64(set[i]-set[i-1])))
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadBinary reads a disjoint set in the binary encoding from r, a set at a
+// time, so that the encoding never needs to be in memory as a whole. Unless r
+// is an io.ByteReader, it may read past the end of the encoding. The sets
+// read have their smallest elements as representatives.
+func ReadBinary(r io.Reader) (DisjointSet, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	s := &disjointSet{map[int]int{}, map[int]int{}}
+	uvarint := func() (uint64, error) { return binary.ReadUvarint(br) }
+
+	version, err := br.ReadByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if version != binaryVersion {
+		return nil, fmt.Errorf("%w: unknown version %d", ErrCorrupt, version)
+	}
+	count, err := uvarint()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	var set []int
+	for ; count > 0; count-- {
+		size, err := uvarint()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if size == 0 {
+			return nil, fmt.Errorf("%w: empty set", ErrCorrupt)
+		}
+		first, err := binary.ReadVarint(br)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if int64(int(first)) != first {
+			return nil, fmt.Errorf("%w: element %d out of range", ErrCorrupt, first)
+		}
+		set = append(set[:0], int(first))
+		for ; size > 1; size-- {
+			gap, err := uvarint()
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			prev := set[len(set)-1]
+			// the gap from prev to math.MaxInt, which fits in a uint64
+			if gap == 0 || gap > uint64(math.MaxInt)-uint64(prev) {
+				return nil, fmt.Errorf("%w: bad gap %d after %d", ErrCorrupt, gap, prev)
+			}
+			set = append(set, prev+int(gap))
+		}
+		if err := s.add(set); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// unexpectedEOF turns io.EOF, which means the encoding is cut short, into
+// io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// ReadJSON reads a disjoint set in the JSON encoding, an array of sets of
+// elements such as [[1,2,5],[3,4]], from r, a set at a time. The sets need
+// not be sorted, but no element may be in two of them. The sets read have
+// their smallest elements as representatives.
+func ReadJSON(r io.Reader) (DisjointSet, error) {
+	dec := json.NewDecoder(r)
+	s := &disjointSet{map[int]int{}, map[int]int{}}
+	if tok, err := dec.Token(); err != nil {
+		return nil, unexpectedEOF(err)
+	} else if tok != json.Delim('[') {
+		return nil, fmt.Errorf("%w: %v instead of an array", ErrCorrupt, tok)
+	}
+	for dec.More() {
+		var set []int
+		if err := dec.Decode(&set); err != nil {
+			return nil, err
+		}
+		if err := s.add(set); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return s, nil
+}
+
+// MarshalBinary encodes the partition of s as WriteBinary does.
+func (s *disjointSet) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	err := WriteBinary(&buf, s)
+	return buf.Bytes(), err
+}
+
+// UnmarshalBinary replaces s with the disjoint set that data encodes.
+func (s *disjointSet) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	decoded, err := ReadBinary(r)
+	if err != nil {
+		return err
+	}
+	if r.Len() > 0 {
+		return fmt.Errorf("%w: %d bytes after the end", ErrCorrupt, r.Len())
+	}
+	*s = *decoded.(*disjointSet)
+	return nil
+}
+
+// MarshalJSON encodes the partition of s as an array of the arrays of Sets.
+func (s *disjointSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sets(s, s))
+}
+
+// UnmarshalJSON replaces s with the disjoint set that data encodes.
+func (s *disjointSet) UnmarshalJSON(data []byte) error {
+	var sets [][]int
+	if err := json.Unmarshal(data, &sets); err != nil {
+		return err
+	}
+	decoded := &disjointSet{map[int]int{}, map[int]int{}}
+	for _, set := range sets {
+		if err := decoded.add(set); err != nil {
+			return err
+		}
+	}
+	*s = *decoded
+	return nil
+}
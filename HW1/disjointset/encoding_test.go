@@ -0,0 +1,231 @@
+package disjointset
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = &disjointSet{}
+	_ encoding.BinaryUnmarshaler = &disjointSet{}
+	_ json.Marshaler             = &disjointSet{}
+	_ json.Unmarshaler           = &disjointSet{}
+)
+
+// mustSets returns Sets(s), failing the test if Sets gives an error.
+func mustSets(t *testing.T, s DisjointSet) [][]int {
+	t.Helper()
+	sets, err := Sets(s)
+	if err != nil {
+		t.Fatalf("Sets gives %v", err)
+	}
+	return sets
+}
+
+// mustEqual returns Equal(a, b), failing the test if Equal gives an error.
+func mustEqual(t *testing.T, a, b DisjointSet) bool {
+	t.Helper()
+	equal, err := Equal(a, b)
+	if err != nil {
+		t.Fatalf("Equal gives %v", err)
+	}
+	return equal
+}
+
+func TestSets(t *testing.T) {
+	s := NewDisjointSet()
+	s.UnionSet(5, 1)
+	s.UnionSet(-3, 4)
+	s.UnionSet(1, 2)
+	s.FindSet(7)
+	want := [][]int{{-3, 4}, {1, 2, 5}}
+	if sets := mustSets(t, s); !reflect.DeepEqual(sets, want) {
+		t.Errorf("Sets=%v; want %v", sets, want)
+	}
+	if sets := mustSets(t, NewDisjointSet()); sets == nil || len(sets) != 0 {
+		t.Errorf("Sets of an empty set=%#v; want an empty slice", sets)
+	}
+
+	c := NewConcurrentDisjointSet(4)
+	c.UnionSet(3, 1)
+	if sets := mustSets(t, c); !reflect.DeepEqual(sets, [][]int{{1, 3}}) {
+		t.Errorf("Sets of a ConcurrentDisjointSet=%v; want [[1 3]]", sets)
+	}
+
+	// the sets of other packages cannot list their elements
+	foreign := &lockedDisjointSet{set: NewDisjointSet()}
+	if sets, err := Sets(foreign); sets != nil || !errors.Is(err, ErrNotListable) {
+		t.Errorf("Sets of a foreign DisjointSet=%v, %v; want ErrNotListable", sets, err)
+	}
+	if _, err := Equal(NewDisjointSet(), foreign); !errors.Is(err, ErrNotListable) {
+		t.Errorf("Equal with a foreign DisjointSet gives %v; want ErrNotListable", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteBinary(&buf, foreign); !errors.Is(err, ErrNotListable) || buf.Len() > 0 {
+		t.Errorf("WriteBinary of a foreign DisjointSet gives %v and %d bytes; want ErrNotListable", err, buf.Len())
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a, b := NewDisjointSet(), NewRollbackDisjointSet()
+	for _, u := range [][2]int{{1, 2}, {3, 4}, {2, 3}} {
+		a.UnionSet(u[0], u[1])
+	}
+	for _, u := range [][2]int{{4, 1}, {2, 4}, {3, 2}, {9, 9}} {
+		b.UnionSet(u[0], u[1])
+	}
+	if !mustEqual(t, a, b) {
+		t.Errorf("Expected %v and %v to be equal", mustSets(t, a), mustSets(t, b))
+	}
+	c := b.Checkpoint()
+	b.UnionSet(4, 5)
+	if mustEqual(t, a, b) {
+		t.Errorf("Expected %v and %v to differ", mustSets(t, a), mustSets(t, b))
+	}
+	b.Rollback(c)
+	b.UnionSet(6, 7)
+	if mustEqual(t, a, b) {
+		t.Errorf("Expected %v and %v to differ", mustSets(t, a), mustSets(t, b))
+	}
+}
+
+// randomSet returns a disjoint set of random unions of elements around 0.
+func randomSet(rng *rand.Rand, unions int) DisjointSet {
+	s := NewDisjointSet()
+	for i := 0; i < unions; i++ {
+		s.UnionSet(rng.Intn(200)-100, rng.Intn(200)-100)
+	}
+	return s
+}
+
+func TestEncodingRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for round := 0; round < 20; round++ {
+		s := randomSet(rng, rng.Intn(150))
+		s.UnionSet(math.MinInt, math.MaxInt)
+
+		data, err := s.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary: %v", err)
+		}
+		decoded := NewDisjointSet()
+		if err := decoded.(encoding.BinaryUnmarshaler).UnmarshalBinary(data); err != nil {
+			t.Fatalf("UnmarshalBinary: %v", err)
+		}
+		if !mustEqual(t, s, decoded) {
+			t.Errorf("Binary round trip of %v gives %v", mustSets(t, s), mustSets(t, decoded))
+		}
+
+		text, err := json.Marshal(s)
+		if err != nil {
+			t.Fatalf("json.Marshal: %v", err)
+		}
+		decoded = NewDisjointSet()
+		if err := json.Unmarshal(text, decoded); err != nil {
+			t.Fatalf("json.Unmarshal: %v", err)
+		}
+		if !mustEqual(t, s, decoded) {
+			t.Errorf("JSON round trip of %s gives %v", text, mustSets(t, decoded))
+		}
+
+		streamed, err := ReadJSON(bytes.NewReader(text))
+		if err != nil || !mustEqual(t, s, streamed) {
+			t.Errorf("ReadJSON(%s) gives %v, %v", text, mustSets(t, streamed), err)
+		}
+	}
+}
+
+func TestEncodingCanonical(t *testing.T) {
+	s := NewDisjointSet()
+	s.UnionSet(9, 2)
+	s.UnionSet(4, 3)
+	s.UnionSet(3, 9)
+	text, _ := json.Marshal(s)
+	if string(text) != "[[2,3,4,9]]" {
+		t.Errorf("json.Marshal gives %s; want [[2,3,4,9]]", text)
+	}
+	data, _ := s.(encoding.BinaryMarshaler).MarshalBinary()
+	if want := []byte{binaryVersion, 1, 4, 4, 1, 1, 5}; !bytes.Equal(data, want) {
+		t.Errorf("MarshalBinary gives %v; want %v", data, want)
+	}
+
+	decoded, err := ReadBinary(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadBinary: %v", err)
+	}
+	for _, x := range []int{2, 3, 4, 9} {
+		if r := decoded.FindSet(x); r != 2 {
+			t.Errorf("FindSet(%d)=%d after decoding; want 2", x, r)
+		}
+	}
+}
+
+// Writing and reading a large set a set at a time through a pipe.
+func TestEncodingStream(t *testing.T) {
+	s := NewDisjointSet()
+	for i := 0; i < 100000; i += 2 {
+		s.UnionSet(i, i+1)
+	}
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(WriteBinary(w, s))
+	}()
+	decoded, err := ReadBinary(r)
+	if err != nil {
+		t.Fatalf("ReadBinary: %v", err)
+	}
+	if !mustEqual(t, s, decoded) {
+		t.Errorf("Streamed set differs")
+	}
+}
+
+func TestDecodingErrors(t *testing.T) {
+	binaryTests := []struct {
+		data []byte
+		err  error
+	}{
+		{nil, io.ErrUnexpectedEOF},
+		{[]byte{2, 0}, ErrCorrupt},
+		{[]byte{binaryVersion, 1, 2, 4}, io.ErrUnexpectedEOF},
+		{[]byte{binaryVersion, 1, 0}, ErrCorrupt},
+		{[]byte{binaryVersion, 1, 2, 4, 0}, ErrCorrupt},
+		{[]byte{binaryVersion, 2, 2, 4, 1, 2, 6, 1}, ErrCorrupt},
+		{[]byte{binaryVersion, 0, 0}, ErrCorrupt},
+		{[]byte{binaryVersion, 1, 2, 0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01, 1}, ErrCorrupt},
+	}
+	for i, test := range binaryTests {
+		err := NewDisjointSet().(encoding.BinaryUnmarshaler).UnmarshalBinary(test.data)
+		if !errors.Is(err, test.err) {
+			t.Errorf("#%d: UnmarshalBinary(%v)=%v; want %v", i, test.data, err, test.err)
+		}
+	}
+
+	jsonTests := []struct {
+		text string
+		err  error
+	}{
+		{"", io.ErrUnexpectedEOF},
+		{"{}", ErrCorrupt},
+		{"[[1,2],[2,3]]", ErrCorrupt},
+		{"[[1,1]]", ErrCorrupt},
+	}
+	for i, test := range jsonTests {
+		if _, err := ReadJSON(strings.NewReader(test.text)); !errors.Is(err, test.err) {
+			t.Errorf("#%d: ReadJSON(%q)=%v; want %v", i, test.text, err, test.err)
+		}
+	}
+	if _, err := ReadJSON(strings.NewReader("[[1,2]")); err == nil {
+		t.Errorf("ReadJSON of a cut short array gives no error")
+	}
+	if err := json.Unmarshal([]byte(`[[1,2],[3,1]]`), NewDisjointSet()); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("json.Unmarshal of overlapping sets gives %v; want ErrCorrupt", err)
+	}
+}
@@ -0,0 +1,68 @@
+package disjointset
+
+// DisjointSetOf is a disjoint set over keys of any comparable type, such as
+// strings, structs or pointers. It gives every key a dense index the first
+// time Index or UnionSet sees it, and keeps the sets of indices in a
+// DisjointSet.
+type DisjointSetOf[K comparable] struct {
+	index map[K]int
+	keys  []K
+	set   DisjointSet
+}
+
+// NewDisjointSetOf returns an empty DisjointSetOf.
+func NewDisjointSetOf[K comparable]() *DisjointSetOf[K] {
+	return &DisjointSetOf[K]{index: map[K]int{}, set: NewDisjointSet()}
+}
+
+// Index returns the index of k, giving it the next free index if k is new.
+func (s *DisjointSetOf[K]) Index(k K) int {
+	i, ok := s.index[k]
+	if !ok {
+		i = len(s.keys)
+		s.index[k] = i
+		s.keys = append(s.keys, k)
+	}
+	return i
+}
+
+// Key returns the key of index i. It panics if no key has index i.
+func (s *DisjointSetOf[K]) Key(i int) K {
+	return s.keys[i]
+}
+
+// Len returns the number of keys that have an index.
+func (s *DisjointSetOf[K]) Len() int {
+	return len(s.keys)
+}
+
+// UnionSet merges the sets containing a and b, and returns the representative
+// of the merged set.
+func (s *DisjointSetOf[K]) UnionSet(a, b K) K {
+	return s.keys[s.set.UnionSet(s.Index(a), s.Index(b))]
+}
+
+// Contains reports whether k has an index, that is, whether Index or UnionSet
+// has seen it.
+func (s *DisjointSetOf[K]) Contains(k K) bool {
+	_, ok := s.index[k]
+	return ok
+}
+
+// FindSet returns the representative of the set that k belongs to. A key
+// without an index is alone in its set, so FindSet returns it as it is, and
+// gives it no index.
+func (s *DisjointSetOf[K]) FindSet(k K) K {
+	i, ok := s.index[k]
+	if !ok {
+		return k
+	}
+	return s.keys[s.set.FindSet(i)]
+}
+
+// Ints returns the sets of s as a DisjointSet over the indices of the keys.
+// Both share the same state, so unions made through either show in the other.
+// Indices that Index has not given out yet have no key.
+func (s *DisjointSetOf[K]) Ints() DisjointSet {
+	return s.set
+}
@@ -0,0 +1,92 @@
+package disjointset
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestDisjointSetOf(t *testing.T) {
+	for testNo, test := range disjointSetTests {
+		s := NewDisjointSetOf[string]()
+		for _, unionSetStep := range test.unionSetSteps {
+			s.UnionSet(strconv.Itoa(unionSetStep.a), strconv.Itoa(unionSetStep.b))
+		}
+		for _, findSetCheck := range test.findSetChecks {
+			a, b := strconv.Itoa(findSetCheck.a), strconv.Itoa(findSetCheck.b)
+			if actual := s.FindSet(a) == s.FindSet(b); actual != findSetCheck.expected {
+				t.Errorf("In test %d, FindSet(%q) == FindSet(%q) gives %t, expected %t",
+					testNo+1, a, b, actual, findSetCheck.expected)
+			}
+		}
+	}
+}
+
+func TestDisjointSetOfKeys(t *testing.T) {
+	type point struct{ x, y int }
+	points := NewDisjointSetOf[point]()
+	if r := points.UnionSet(point{0, 0}, point{1, 0}); r != (point{0, 0}) && r != (point{1, 0}) {
+		t.Errorf("UnionSet gives %v, which is neither key", r)
+	}
+	points.UnionSet(point{1, 0}, point{1, 1})
+	if points.FindSet(point{0, 0}) != points.FindSet(point{1, 1}) {
+		t.Errorf("Expected (0, 0) and (1, 1) to be in the same set")
+	}
+	if r := points.FindSet(point{5, 5}); r != (point{5, 5}) {
+		t.Errorf("FindSet((5, 5))=%v; want (5, 5)", r)
+	}
+
+	// pointers are keys by identity, not by what they point to
+	a, b, c := new(int), new(int), new(int)
+	pointers := NewDisjointSetOf[*int]()
+	pointers.UnionSet(a, b)
+	if pointers.FindSet(a) != pointers.FindSet(b) || pointers.FindSet(a) == pointers.FindSet(c) {
+		t.Errorf("Expected a and b together and c apart")
+	}
+}
+
+func TestDisjointSetOfInts(t *testing.T) {
+	s := NewDisjointSetOf[string]()
+	for i, k := range []string{"x", "y", "z", "w"} {
+		if index := s.Index(k); index != i {
+			t.Errorf("Index(%q)=%d; want %d", k, index, i)
+		}
+	}
+	if index := s.Index("y"); index != 1 {
+		t.Errorf("Index(%q)=%d again; want 1", "y", index)
+	}
+	if s.Len() != 4 || s.Key(2) != "z" {
+		t.Errorf("Len()=%d, Key(2)=%q; want 4, %q", s.Len(), s.Key(2), "z")
+	}
+
+	ints := s.Ints()
+	ints.UnionSet(0, 3)
+	if s.FindSet("x") != s.FindSet("w") {
+		t.Errorf("Expected the union of indices 0 and 3 to unite x and w")
+	}
+	s.UnionSet("y", "z")
+	if ints.FindSet(1) != ints.FindSet(2) || ints.FindSet(1) == ints.FindSet(0) {
+		t.Errorf("Expected indices 1 and 2 together and 0 apart")
+	}
+	if r := s.Key(ints.FindSet(3)); r != s.FindSet("w") {
+		t.Errorf("The representative of index 3 is %q, of w is %q", r, s.FindSet("w"))
+	}
+
+	// looking up a key that was never added changes nothing
+	before := mustSets(t, ints)
+	if r := s.FindSet("v"); r != "v" {
+		t.Errorf("FindSet(%q)=%q; want %q", "v", r, "v")
+	}
+	if s.Contains("v") || !s.Contains("x") {
+		t.Errorf("Contains(%q)=%t, Contains(%q)=%t; want false, true", "v", s.Contains("v"), "x", s.Contains("x"))
+	}
+	if after := mustSets(t, s.Ints()); s.Len() != 4 || !reflect.DeepEqual(after, before) {
+		t.Errorf("After FindSet(%q), Len()=%d and Ints() has %v; want 4 and %v", "v", s.Len(), after, before)
+	}
+	if r := ints.FindSet(4); r != 4 {
+		t.Errorf("After FindSet(%q), index 4 is in the set of %d; want alone", "v", r)
+	}
+	if index := s.Index("v"); index != 4 {
+		t.Errorf("Index(%q)=%d; want 4", "v", index)
+	}
+}
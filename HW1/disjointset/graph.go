@@ -0,0 +1,113 @@
+package disjointset
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrClusterCount is the error value returned by Clusters if the number of
+// clusters asked for is not between 1 and the number of nodes.
+var ErrClusterCount = errors.New("cluster count out of range")
+
+// Edge is an undirected weighted edge between the nodes U and V.
+type Edge struct {
+	U, V   int
+	Weight float64
+}
+
+// sortedEdges returns a copy of edges sorted by weight, keeping the order of
+// edges of equal weight.
+func sortedEdges(edges []Edge) []Edge {
+	sorted := append([]Edge(nil), edges...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Weight < sorted[j].Weight })
+	return sorted
+}
+
+// MinimumSpanningForest returns the edges of a minimum spanning forest of the
+// graph of edges, in the order Kruskal's algorithm picks them: by weight, and
+// edges of equal weight in the order given. Self-loops and edges between
+// nodes that are connected already are left out.
+func MinimumSpanningForest(edges []Edge) []Edge {
+	var forest []Edge
+	d := NewCycleDetector()
+	for _, e := range sortedEdges(edges) {
+		if !d.Add(e.U, e.V) {
+			forest = append(forest, e)
+		}
+	}
+	return forest
+}
+
+// Clusters splits the nodes 0 to n-1 into k clusters by single linkage: it
+// unites the nodes of the lightest edges first, like Kruskal's algorithm,
+// until k clusters are left, which maximizes the lightest edge between two
+// clusters. It returns the cluster of every node, numbering the clusters
+// from 0 in the order of their smallest nodes. If the edges leave more than
+// k connected components, there are that many clusters instead. It returns
+// ErrClusterCount if k is not between 1 and n, and ignores the edges of
+// nodes outside 0 to n-1.
+func Clusters(n int, edges []Edge, k int) ([]int, error) {
+	if k < 1 || k > n {
+		return nil, ErrClusterCount
+	}
+	s := NewDisjointSet()
+	count := n
+	for _, e := range sortedEdges(edges) {
+		if count == k {
+			break
+		}
+		if e.U < 0 || e.U >= n || e.V < 0 || e.V >= n {
+			continue
+		}
+		if s.FindSet(e.U) != s.FindSet(e.V) {
+			s.UnionSet(e.U, e.V)
+			count--
+		}
+	}
+
+	cluster := make([]int, n)
+	number := map[int]int{}
+	for i := range cluster {
+		root := s.FindSet(i)
+		c, ok := number[root]
+		if !ok {
+			c = len(number)
+			number[root] = c
+		}
+		cluster[i] = c
+	}
+	return cluster, nil
+}
+
+// CycleDetector reports the edges of a stream of undirected edges that close
+// a cycle with the edges before them.
+type CycleDetector struct {
+	set DisjointSet
+}
+
+// NewCycleDetector returns a CycleDetector that has seen no edges.
+func NewCycleDetector() *CycleDetector {
+	return &CycleDetector{NewDisjointSet()}
+}
+
+// Add adds the edge between u and v and reports whether it closes a cycle,
+// that is, whether u and v were connected already. A self-loop always does.
+func (d *CycleDetector) Add(u, v int) bool {
+	if d.set.FindSet(u) == d.set.FindSet(v) {
+		return true
+	}
+	d.set.UnionSet(u, v)
+	return false
+}
+
+// FirstCycle returns the index of the first edge that closes a cycle with the
+// edges before it, or -1 if the edges form a forest.
+func FirstCycle(edges []Edge) int {
+	d := NewCycleDetector()
+	for i, e := range edges {
+		if d.Add(e.U, e.V) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,141 @@
+package disjointset
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestMinimumSpanningForest(t *testing.T) {
+	tests := []struct {
+		edges []Edge
+		want  []Edge
+	}{
+		{nil, nil},
+		{[]Edge{{1, 1, 0}}, nil},
+		{
+			[]Edge{{0, 1, 4}, {1, 2, 2}, {0, 2, 3}, {2, 3, 1}, {1, 3, 5}},
+			[]Edge{{2, 3, 1}, {1, 2, 2}, {0, 2, 3}},
+		},
+		// two components, and ties kept in order
+		{
+			[]Edge{{0, 1, 1}, {5, 6, 1}, {1, 2, 1}, {0, 2, 1}, {6, 7, 2}, {5, 7, 0}},
+			[]Edge{{5, 7, 0}, {0, 1, 1}, {5, 6, 1}, {1, 2, 1}},
+		},
+	}
+	for i, test := range tests {
+		if got := MinimumSpanningForest(test.edges); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("#%d: MinimumSpanningForest(%v)=%v; want %v", i, test.edges, got, test.want)
+		}
+	}
+}
+
+// The forest has the weight of the lightest spanning forest found by brute
+// force over all subsets of edges that connect the same components.
+func TestMinimumSpanningForestRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for round := 0; round < 50; round++ {
+		edges := make([]Edge, rng.Intn(10))
+		for i := range edges {
+			edges[i] = Edge{rng.Intn(6), rng.Intn(6), float64(rng.Intn(10))}
+		}
+		components := func(edges []Edge) DisjointSet {
+			s := NewDisjointSet()
+			for _, e := range edges {
+				s.UnionSet(e.U, e.V)
+			}
+			return s
+		}
+		all := components(edges)
+		best := -1.0
+		for mask := 0; mask < 1<<len(edges); mask++ {
+			var subset []Edge
+			weight := 0.0
+			for i, e := range edges {
+				if mask&(1<<i) != 0 {
+					subset = append(subset, e)
+					weight += e.Weight
+				}
+			}
+			s := components(subset)
+			spanning := true
+			for u := 0; u < 6; u++ {
+				for v := 0; v < 6; v++ {
+					if (all.FindSet(u) == all.FindSet(v)) != (s.FindSet(u) == s.FindSet(v)) {
+						spanning = false
+					}
+				}
+			}
+			if spanning && (best < 0 || weight < best) {
+				best = weight
+			}
+		}
+
+		forest := MinimumSpanningForest(edges)
+		weight := 0.0
+		for _, e := range forest {
+			weight += e.Weight
+		}
+		if weight != best || FirstCycle(forest) != -1 {
+			t.Errorf("MinimumSpanningForest(%v)=%v of weight %v; want a forest of weight %v",
+				edges, forest, weight, best)
+		}
+	}
+}
+
+func TestClusters(t *testing.T) {
+	// two tight groups {0, 1, 2} and {3, 4}, and 5 far from both
+	edges := []Edge{
+		{0, 1, 1}, {1, 2, 2}, {3, 4, 1}, {2, 3, 10}, {4, 5, 20}, {0, 5, 30},
+	}
+	tests := []struct {
+		n, k int
+		want []int
+		err  error
+	}{
+		{6, 1, []int{0, 0, 0, 0, 0, 0}, nil},
+		{6, 2, []int{0, 0, 0, 0, 0, 1}, nil},
+		{6, 3, []int{0, 0, 0, 1, 1, 2}, nil},
+		{6, 4, []int{0, 0, 1, 2, 2, 3}, nil},
+		{6, 6, []int{0, 1, 2, 3, 4, 5}, nil},
+		// 6 and 7 have no edges, so there are at least 4 clusters
+		{8, 2, []int{0, 0, 0, 0, 0, 0, 1, 2}, nil},
+		{6, 0, nil, ErrClusterCount},
+		{6, 7, nil, ErrClusterCount},
+	}
+	for i, test := range tests {
+		got, err := Clusters(test.n, edges, test.k)
+		if !reflect.DeepEqual(got, test.want) || err != test.err {
+			t.Errorf("#%d: Clusters(%d, edges, %d)=%v, %v; want %v, %v",
+				i, test.n, test.k, got, err, test.want, test.err)
+		}
+	}
+}
+
+func TestCycleDetector(t *testing.T) {
+	tests := []struct {
+		edges []Edge
+		first int
+	}{
+		{nil, -1},
+		{[]Edge{{0, 1, 0}, {1, 2, 0}, {3, 2, 0}}, -1},
+		{[]Edge{{0, 1, 0}, {1, 2, 0}, {2, 0, 0}, {3, 4, 0}}, 2},
+		{[]Edge{{0, 1, 0}, {1, 0, 0}}, 1},
+		{[]Edge{{0, 1, 0}, {2, 2, 0}}, 1},
+	}
+	for i, test := range tests {
+		if first := FirstCycle(test.edges); first != test.first {
+			t.Errorf("#%d: FirstCycle(%v)=%d; want %d", i, test.edges, first, test.first)
+		}
+	}
+
+	d := NewCycleDetector()
+	for i, step := range []struct {
+		u, v  int
+		cycle bool
+	}{{1, 2, false}, {3, 4, false}, {2, 3, false}, {4, 1, true}, {4, 1, true}, {5, 1, false}} {
+		if cycle := d.Add(step.u, step.v); cycle != step.cycle {
+			t.Errorf("#%d: Add(%d, %d)=%t; want %t", i, step.u, step.v, cycle, step.cycle)
+		}
+	}
+}
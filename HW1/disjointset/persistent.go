@@ -0,0 +1,105 @@
+package disjointset
+
+// PersistentDisjointSet is a fully persistent disjoint set: UnionSet leaves the
+// set unchanged and returns a new version, so every version stays usable.
+// Versions share most of their structure: the entries live in a trie of fixed
+// depth 64/trieBits = 16, so a union copies the path to each of the at most two
+// entries it writes, 16 nodes apiece, whatever the number of elements n. It
+// uses union by rank without path compression, so FindSet follows O(log n)
+// parents, each a walk of the 16 levels of the trie. The zero value is an
+// empty set in which every element is alone.
+//
+// PersistentDisjointSet does not satisfy the DisjointSet interface, whose
+// UnionSet changes the set in place.
+type PersistentDisjointSet struct {
+	root *trieNode
+}
+
+// entry is the parent and rank of an element. Elements without an entry are
+// roots of rank 0.
+type entry struct {
+	parent int
+	rank   int
+	set    bool
+}
+
+// The entries are kept in a persistent trie indexed by trieBits bits of the
+// element at a time, most significant first.
+const (
+	trieBits  = 4
+	trieWidth = 1 << trieBits
+	topShift  = 64 - trieBits
+)
+
+// trieNode is a node of the trie. Nodes of the last level have entries, the
+// others have children. Nodes are never changed once built.
+type trieNode struct {
+	children [trieWidth]*trieNode
+	entries  *[trieWidth]entry
+}
+
+func (n *trieNode) get(key uint64) entry {
+	for shift := uint(topShift); n != nil; shift -= trieBits {
+		i := key >> shift & (trieWidth - 1)
+		if shift == 0 {
+			return n.entries[i]
+		}
+		n = n.children[i]
+	}
+	return entry{}
+}
+
+// with returns a copy of the trie rooted at n, which may be nil, in which key
+// has entry e. The copy shares every node off the path to key.
+func (n *trieNode) with(key uint64, shift uint, e entry) *trieNode {
+	result := &trieNode{}
+	if n != nil {
+		*result = *n
+	}
+	i := key >> shift & (trieWidth - 1)
+	if shift == 0 {
+		entries := new([trieWidth]entry)
+		if result.entries != nil {
+			*entries = *result.entries
+		}
+		entries[i] = e
+		result.entries = entries
+		return result
+	}
+	result.children[i] = result.children[i].with(key, shift-trieBits, e)
+	return result
+}
+
+func (s PersistentDisjointSet) get(x int) entry {
+	return s.root.get(uint64(x))
+}
+
+// FindSet returns the representative of the class that x belongs to.
+func (s PersistentDisjointSet) FindSet(x int) int {
+	for {
+		e := s.get(x)
+		if !e.set || e.parent == x {
+			return x
+		}
+		x = e.parent
+	}
+}
+
+// UnionSet returns a new version of s in which the sets containing x and y
+// are merged, and the representative of the merged set.
+func (s PersistentDisjointSet) UnionSet(x, y int) (PersistentDisjointSet, int) {
+	x, y = s.FindSet(x), s.FindSet(y)
+	if x == y {
+		return s, x
+	}
+	ex, ey := s.get(x), s.get(y)
+	if ex.rank < ey.rank {
+		x, y = y, x
+		ex, ey = ey, ex
+	}
+	root := s.root.with(uint64(y), topShift, entry{parent: x, rank: ey.rank, set: true})
+	if ex.rank == ey.rank {
+		root = root.with(uint64(x), topShift, entry{parent: x, rank: ex.rank + 1, set: true})
+	}
+	return PersistentDisjointSet{root}, x
+}
@@ -0,0 +1,84 @@
+package disjointset
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPersistentDisjointSet(t *testing.T) {
+	for testNo, test := range disjointSetTests {
+		var s PersistentDisjointSet
+		for _, unionSetStep := range test.unionSetSteps {
+			s, _ = s.UnionSet(unionSetStep.a, unionSetStep.b)
+		}
+		for _, findSetCheck := range test.findSetChecks {
+			if actual := s.FindSet(findSetCheck.a) == s.FindSet(findSetCheck.b); actual != findSetCheck.expected {
+				t.Errorf("In test %d, FindSet(%d) == FindSet(%d) gives %t, expected %t",
+					testNo+1, findSetCheck.a, findSetCheck.b, actual, findSetCheck.expected)
+			}
+		}
+	}
+}
+
+func TestPersistentVersions(t *testing.T) {
+	var empty PersistentDisjointSet
+	v1, r := empty.UnionSet(1, 2)
+	if r != 1 && r != 2 {
+		t.Errorf("UnionSet(1, 2)=%d; want 1 or 2", r)
+	}
+	v2, _ := v1.UnionSet(-3, 4)
+	v3, _ := v2.UnionSet(2, -3)
+	v4, _ := v1.UnionSet(2, 5)
+
+	tests := []struct {
+		name     string
+		s        PersistentDisjointSet
+		a, b     int
+		expected bool
+	}{
+		{"empty", empty, 1, 2, false},
+		{"v1", v1, 1, 2, true},
+		{"v1", v1, -3, 4, false},
+		{"v2", v2, -3, 4, true},
+		{"v2", v2, 1, 4, false},
+		{"v3", v3, 1, 4, true},
+		{"v4", v4, 1, 5, true},
+		{"v4", v4, -3, 4, false},
+		{"v3", v3, 1, 5, false},
+	}
+	for _, test := range tests {
+		if actual := test.s.FindSet(test.a) == test.s.FindSet(test.b); actual != test.expected {
+			t.Errorf("In %s, FindSet(%d) == FindSet(%d) gives %t, expected %t",
+				test.name, test.a, test.b, actual, test.expected)
+		}
+	}
+	if v, _ := v3.UnionSet(1, 4); v != v3 {
+		t.Errorf("Uniting elements of the same set gives a new version")
+	}
+}
+
+// Every version agrees with a fresh set built from the unions leading to it.
+func TestPersistentRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	versions := []PersistentDisjointSet{{}}
+	unions := [][][2]int{nil}
+	for step := 0; step < 500; step++ {
+		i := rng.Intn(len(versions))
+		a, b := rng.Intn(100)-50, rng.Intn(100)-50
+		v, _ := versions[i].UnionSet(a, b)
+		versions = append(versions, v)
+		unions = append(unions, append(append([][2]int(nil), unions[i]...), [2]int{a, b}))
+	}
+	for i, v := range versions {
+		fresh := NewDisjointSet()
+		for _, u := range unions[i] {
+			fresh.UnionSet(u[0], u[1])
+		}
+		for j := 0; j < 20; j++ {
+			a, b := rng.Intn(100)-50, rng.Intn(100)-50
+			if (v.FindSet(a) == v.FindSet(b)) != (fresh.FindSet(a) == fresh.FindSet(b)) {
+				t.Fatalf("Version %d: %d and %d disagree with a fresh set", i, a, b)
+			}
+		}
+	}
+}
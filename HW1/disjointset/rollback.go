@@ -0,0 +1,90 @@
+package disjointset
+
+// Checkpoint marks a state of a RollbackDisjointSet to roll back to. The zero
+// Checkpoint marks the empty state, before any union.
+type Checkpoint struct {
+	// unions is the length of the log, and last the serial number of its last
+	// union, which tells the state apart from later ones of the same length
+	unions, last int
+}
+
+// RollbackDisjointSet is a DisjointSet whose unions can be undone. It uses
+// union by rank without path compression, so that FindSet takes O(log n) time
+// and every union changes a single parent pointer, which is logged.
+type RollbackDisjointSet struct {
+	parent map[int]int
+	rank   map[int]int
+	log    []merge
+	// serial is the number of unions logged so far, rolled back or not
+	serial int
+}
+
+// merge records that the root child was put under the root parent, and
+// whether the rank of parent went up.
+type merge struct {
+	child, parent int
+	rankUp        bool
+	serial        int
+}
+
+// NewRollbackDisjointSet returns an empty RollbackDisjointSet.
+func NewRollbackDisjointSet() *RollbackDisjointSet {
+	return &RollbackDisjointSet{parent: map[int]int{}, rank: map[int]int{}}
+}
+
+func (s *RollbackDisjointSet) FindSet(x int) int {
+	for {
+		parent, ok := s.parent[x]
+		if !ok {
+			return x
+		}
+		x = parent
+	}
+}
+
+func (s *RollbackDisjointSet) UnionSet(x, y int) int {
+	x, y = s.FindSet(x), s.FindSet(y)
+	if x == y {
+		return x
+	}
+	if s.rank[x] < s.rank[y] {
+		x, y = y, x
+	}
+	s.parent[y] = x
+	s.serial++
+	m := merge{child: y, parent: x, serial: s.serial}
+	if s.rank[x] == s.rank[y] {
+		s.rank[x]++
+		m.rankUp = true
+	}
+	s.log = append(s.log, m)
+	return x
+}
+
+// Checkpoint returns a checkpoint of the current state of s.
+func (s *RollbackDisjointSet) Checkpoint() Checkpoint {
+	if len(s.log) == 0 {
+		return Checkpoint{}
+	}
+	return Checkpoint{len(s.log), s.log[len(s.log)-1].serial}
+}
+
+// Rollback undoes every union since c was taken, in O(1) time per union that
+// merged two sets. Checkpoints taken after c are no longer valid. It panics if
+// c marks a state that has since been rolled back, even if unions made since
+// have grown the log back past it.
+func (s *RollbackDisjointSet) Rollback(c Checkpoint) {
+	if c.unions < 0 || c.unions > len(s.log) || c.unions > 0 && s.log[c.unions-1].serial != c.last {
+		panic("disjointset: rollback to an invalid checkpoint")
+	}
+	for len(s.log) > c.unions {
+		m := s.log[len(s.log)-1]
+		s.log = s.log[:len(s.log)-1]
+		delete(s.parent, m.child)
+		if m.rankUp {
+			if s.rank[m.parent]--; s.rank[m.parent] == 0 {
+				delete(s.rank, m.parent)
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package disjointset
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRollbackDisjointSet(t *testing.T) {
+	for testNo, test := range disjointSetTests {
+		s := NewRollbackDisjointSet()
+		for _, unionSetStep := range test.unionSetSteps {
+			s.UnionSet(unionSetStep.a, unionSetStep.b)
+		}
+		for _, findSetCheck := range test.findSetChecks {
+			if actual := s.FindSet(findSetCheck.a) == s.FindSet(findSetCheck.b); actual != findSetCheck.expected {
+				t.Errorf("In test %d, FindSet(%d) == FindSet(%d) gives %t, expected %t",
+					testNo+1, findSetCheck.a, findSetCheck.b, actual, findSetCheck.expected)
+			}
+		}
+	}
+}
+
+func TestRollback(t *testing.T) {
+	s := NewRollbackDisjointSet()
+	s.UnionSet(1, 2)
+	c1 := s.Checkpoint()
+	s.UnionSet(3, 4)
+	s.UnionSet(2, 3)
+	c2 := s.Checkpoint()
+	s.UnionSet(1, 4) // already together: nothing to undo
+	s.UnionSet(5, 1)
+	if s.FindSet(5) != s.FindSet(3) {
+		t.Errorf("Expected 5 and 3 to be in the same set")
+	}
+
+	s.Rollback(c2)
+	if s.FindSet(5) == s.FindSet(1) {
+		t.Errorf("Expected 5 and 1 to be apart after rolling back")
+	}
+	if s.FindSet(1) != s.FindSet(4) {
+		t.Errorf("Expected 1 and 4 to stay in the same set")
+	}
+
+	s.Rollback(c1)
+	if s.FindSet(1) != s.FindSet(2) {
+		t.Errorf("Expected 1 and 2 to stay in the same set")
+	}
+	if s.FindSet(2) == s.FindSet(3) || s.FindSet(3) == s.FindSet(4) {
+		t.Errorf("Expected 2, 3 and 4 to be apart after rolling back")
+	}
+
+	s.Rollback(Checkpoint{})
+	for i := 1; i <= 5; i++ {
+		if r := s.FindSet(i); r != i {
+			t.Errorf("FindSet(%d)=%d after rolling back everything; want %d", i, r, i)
+		}
+	}
+	if len(s.parent) != 0 || len(s.rank) != 0 {
+		t.Errorf("Expected no entries left, have %d parents and %d ranks", len(s.parent), len(s.rank))
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Rollback to a checkpoint rolled back already did not panic")
+			}
+		}()
+		s.Rollback(c2)
+	}()
+
+	// a checkpoint within the log that marks a state rolled back since
+	s.UnionSet(1, 2)
+	s.UnionSet(3, 4)
+	stale := s.Checkpoint()
+	s.Rollback(Checkpoint{})
+	s.UnionSet(5, 6)
+	s.UnionSet(7, 8)
+	s.UnionSet(9, 10)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Rollback to a stale checkpoint within the log did not panic")
+			}
+		}()
+		s.Rollback(stale)
+	}()
+	if s.FindSet(9) != s.FindSet(10) {
+		t.Errorf("Expected a rejected rollback to leave 9 and 10 together")
+	}
+}
+
+// A backtracking search rolling back after every branch leaves the same sets
+// as a fresh set built from the unions of the current branch.
+func TestRollbackRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	s := NewRollbackDisjointSet()
+	var unions [][2]int
+	var checkpoints []Checkpoint
+	var lengths []int
+	for step := 0; step < 2000; step++ {
+		switch r := rng.Intn(10); {
+		case r < 2:
+			checkpoints = append(checkpoints, s.Checkpoint())
+			lengths = append(lengths, len(unions))
+		case r < 4 && len(checkpoints) > 0:
+			s.Rollback(checkpoints[len(checkpoints)-1])
+			unions = unions[:lengths[len(lengths)-1]]
+			checkpoints, lengths = checkpoints[:len(checkpoints)-1], lengths[:len(lengths)-1]
+		default:
+			a, b := rng.Intn(50), rng.Intn(50)
+			s.UnionSet(a, b)
+			unions = append(unions, [2]int{a, b})
+		}
+
+		fresh := NewDisjointSet()
+		for _, u := range unions {
+			fresh.UnionSet(u[0], u[1])
+		}
+		for i := 0; i < 10; i++ {
+			a, b := rng.Intn(50), rng.Intn(50)
+			if (s.FindSet(a) == s.FindSet(b)) != (fresh.FindSet(a) == fresh.FindSet(b)) {
+				t.Fatalf("Step %d: %d and %d disagree with a fresh set", step, a, b)
+			}
+		}
+	}
+}
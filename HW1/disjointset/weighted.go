@@ -0,0 +1,104 @@
+package disjointset
+
+import "errors"
+
+// ErrContradiction is the error value returned by WeightedDisjointSet.UnionSet
+// if the relation contradicts the ones recorded before.
+var ErrContradiction = errors.New("relation contradicts earlier ones")
+
+// Group is a group whose elements of type T are the offsets between the
+// values of the elements of a WeightedDisjointSet. Combine need not be
+// commutative.
+type Group[T any] interface {
+	Identity() T
+	Combine(a, b T) T
+	Inverse(a T) T
+	Equal(a, b T) bool
+}
+
+// IntSum is the group of the integers under addition, for difference
+// constraints.
+type IntSum struct{}
+
+func (IntSum) Identity() int        { return 0 }
+func (IntSum) Combine(a, b int) int { return a + b }
+func (IntSum) Inverse(a int) int    { return -a }
+func (IntSum) Equal(a, b int) bool  { return a == b }
+
+// Parity is the group of the booleans under exclusive or, for constraints
+// that two elements are the same or differ, such as two-colorings.
+type Parity struct{}
+
+func (Parity) Identity() bool         { return false }
+func (Parity) Combine(a, b bool) bool { return a != b }
+func (Parity) Inverse(a bool) bool    { return a }
+func (Parity) Equal(a, b bool) bool   { return a == b }
+
+// WeightedDisjointSet is a disjoint set that also records how the values of
+// the elements of a set relate: every element x has an unknown value(x), and
+// a union records that value(b) = value(a)·w, that is, value(b) - value(a) = w
+// when the group is written additively. It uses union by rank and path
+// compression.
+type WeightedDisjointSet[T any] struct {
+	group  Group[T]
+	parent map[int]int
+	rank   map[int]int
+	// value(x) = value(parent[x])·offset[x]
+	offset map[int]T
+}
+
+// NewWeightedDisjointSet returns an empty WeightedDisjointSet whose offsets
+// are elements of g.
+func NewWeightedDisjointSet[T any](g Group[T]) *WeightedDisjointSet[T] {
+	return &WeightedDisjointSet[T]{g, map[int]int{}, map[int]int{}, map[int]T{}}
+}
+
+// FindSet returns the representative r of the set that x belongs to, and the
+// offset w such that value(x) = value(r)·w.
+func (s *WeightedDisjointSet[T]) FindSet(x int) (int, T) {
+	parent, ok := s.parent[x]
+	if !ok {
+		return x, s.group.Identity()
+	}
+	root, w := s.FindSet(parent)
+	w = s.group.Combine(w, s.offset[x])
+	s.parent[x], s.offset[x] = root, w
+	return root, w
+}
+
+// UnionSet records that value(b) = value(a)·w, merging the sets containing a
+// and b, and returns the representative of the merged set. If a and b are in
+// the same set already and their values relate differently, it leaves s
+// unchanged and returns ErrContradiction.
+func (s *WeightedDisjointSet[T]) UnionSet(a, b int, w T) (int, error) {
+	g := s.group
+	ra, wa := s.FindSet(a)
+	rb, wb := s.FindSet(b)
+	if ra == rb {
+		if !g.Equal(wb, g.Combine(wa, w)) {
+			return ra, ErrContradiction
+		}
+		return ra, nil
+	}
+	// value(rb) = value(ra)·wa·w·wb⁻¹
+	between := g.Combine(g.Combine(wa, w), g.Inverse(wb))
+	if s.rank[ra] < s.rank[rb] {
+		ra, rb, between = rb, ra, g.Inverse(between)
+	}
+	s.parent[rb], s.offset[rb] = ra, between
+	if s.rank[ra] == s.rank[rb] {
+		s.rank[ra]++
+	}
+	return ra, nil
+}
+
+// Difference returns (w, true) if a and b are in the same set, where
+// value(b) = value(a)·w; else it returns the identity and false.
+func (s *WeightedDisjointSet[T]) Difference(a, b int) (T, bool) {
+	ra, wa := s.FindSet(a)
+	rb, wb := s.FindSet(b)
+	if ra != rb {
+		return s.group.Identity(), false
+	}
+	return s.group.Combine(s.group.Inverse(wa), wb), true
+}
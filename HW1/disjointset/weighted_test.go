@@ -0,0 +1,158 @@
+package disjointset
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestWeightedDisjointSet(t *testing.T) {
+	for testNo, test := range disjointSetTests {
+		s := NewWeightedDisjointSet[int](IntSum{})
+		for _, unionSetStep := range test.unionSetSteps {
+			s.UnionSet(unionSetStep.a, unionSetStep.b, 0)
+		}
+		for _, findSetCheck := range test.findSetChecks {
+			ra, _ := s.FindSet(findSetCheck.a)
+			rb, _ := s.FindSet(findSetCheck.b)
+			if actual := ra == rb; actual != findSetCheck.expected {
+				t.Errorf("In test %d, FindSet(%d) == FindSet(%d) gives %t, expected %t",
+					testNo+1, findSetCheck.a, findSetCheck.b, actual, findSetCheck.expected)
+			}
+		}
+	}
+}
+
+func TestDifferenceConstraints(t *testing.T) {
+	s := NewWeightedDisjointSet[int](IntSum{})
+	steps := []struct {
+		a, b, w int
+		err     error
+	}{
+		{0, 1, 3, nil},
+		{1, 2, 4, nil},
+		{3, 4, -2, nil},
+		{0, 2, 7, nil},
+		{0, 2, 5, ErrContradiction},
+		{4, 2, 1, nil},
+		{3, 0, -6, ErrContradiction},
+		{3, 0, -8, nil},
+	}
+	for i, step := range steps {
+		if _, err := s.UnionSet(step.a, step.b, step.w); err != step.err {
+			t.Errorf("#%d: UnionSet(%d, %d, %d)=%v; want %v", i, step.a, step.b, step.w, err, step.err)
+		}
+	}
+
+	// value(1)-value(0)=3, value(2)-value(0)=7, value(4)-value(0)=6, value(3)-value(0)=8
+	tests := []struct {
+		a, b, want int
+	}{
+		{0, 1, 3},
+		{1, 0, -3},
+		{0, 2, 7},
+		{1, 2, 4},
+		{0, 3, 8},
+		{3, 4, -2},
+		{4, 1, -3},
+		{2, 2, 0},
+	}
+	for i, test := range tests {
+		if w, ok := s.Difference(test.a, test.b); !ok || w != test.want {
+			t.Errorf("#%d: Difference(%d, %d)=%d, %t; want %d, true", i, test.a, test.b, w, ok, test.want)
+		}
+	}
+	if _, ok := s.Difference(0, 5); ok {
+		t.Errorf("Difference(0, 5) reports 0 and 5 in the same set")
+	}
+	if r, w := s.FindSet(5); r != 5 || w != 0 {
+		t.Errorf("FindSet(5)=%d, %d; want 5, 0", r, w)
+	}
+}
+
+func TestParity(t *testing.T) {
+	tests := []struct {
+		edges     [][2]int
+		bipartite bool
+	}{
+		{nil, true},
+		{[][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 0}}, true},
+		{[][2]int{{0, 1}, {1, 2}, {2, 0}}, false},
+		{[][2]int{{0, 1}, {2, 3}, {4, 5}, {1, 3}, {3, 5}, {5, 0}}, true},
+		{[][2]int{{0, 1}, {2, 3}, {4, 5}, {1, 3}, {3, 5}, {4, 0}}, false},
+	}
+	for i, test := range tests {
+		s := NewWeightedDisjointSet[bool](Parity{})
+		bipartite := true
+		for _, e := range test.edges {
+			if _, err := s.UnionSet(e[0], e[1], true); err == ErrContradiction {
+				bipartite = false
+			}
+		}
+		if bipartite != test.bipartite {
+			t.Errorf("#%d: bipartite(%v)=%t; want %t", i, test.edges, bipartite, test.bipartite)
+		}
+		if bipartite {
+			for _, e := range test.edges {
+				if differ, ok := s.Difference(e[0], e[1]); !ok || !differ {
+					t.Errorf("#%d: %d and %d have the same color", i, e[0], e[1])
+				}
+			}
+		}
+	}
+}
+
+// permutations of three elements, a group that is not commutative
+type perm [3]int
+
+type permutations struct{}
+
+func (permutations) Identity() perm { return perm{0, 1, 2} }
+func (permutations) Combine(a, b perm) perm {
+	return perm{b[a[0]], b[a[1]], b[a[2]]}
+}
+func (permutations) Inverse(a perm) perm {
+	var inv perm
+	for i, j := range a {
+		inv[j] = i
+	}
+	return inv
+}
+func (permutations) Equal(a, b perm) bool { return a == b }
+
+// Relations between random hidden values are never contradictions, and the
+// differences found agree with the hidden values.
+func TestWeightedRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var g permutations
+	values := make([]perm, 50)
+	for i := range values {
+		p := rng.Perm(3)
+		values[i] = perm{p[0], p[1], p[2]}
+	}
+	s := NewWeightedDisjointSet[perm](g)
+	fresh := NewDisjointSet()
+	for step := 0; step < 200; step++ {
+		a, b := rng.Intn(len(values)), rng.Intn(len(values))
+		w := g.Combine(g.Inverse(values[a]), values[b])
+		if _, err := s.UnionSet(a, b, w); err != nil {
+			t.Fatalf("Step %d: UnionSet(%d, %d, %v)=%v; want nil", step, a, b, w, err)
+		}
+		fresh.UnionSet(a, b)
+
+		a, b = rng.Intn(len(values)), rng.Intn(len(values))
+		w, ok := s.Difference(a, b)
+		if ok != (fresh.FindSet(a) == fresh.FindSet(b)) {
+			t.Fatalf("Step %d: %d and %d disagree with a fresh set", step, a, b)
+		}
+		if ok && !g.Equal(g.Combine(values[a], w), values[b]) {
+			t.Fatalf("Step %d: Difference(%d, %d)=%v; want %v", step, a, b, w,
+				g.Combine(g.Inverse(values[a]), values[b]))
+		}
+		if ok {
+			wrong := g.Combine(w, perm{1, 0, 2})
+			if _, err := s.UnionSet(a, b, wrong); err != ErrContradiction {
+				t.Fatalf("Step %d: UnionSet(%d, %d, %v)=%v; want ErrContradiction", step, a, b, wrong, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// ErrStride is returned by the filters for a stride that is not positive.
+var ErrStride = errors.New("stride is not positive")
+
+// ErrPadding is returned by the filters for a Padding that is none of PadZero,
+// PadReplicate and PadWrap.
+var ErrPadding = errors.New("unknown padding")
+
+// Padding is how the filters fill in the entries beyond the edges of a
+// matrix that the kernel covers.
+type Padding int
+
+const (
+	// PadZero takes the entries beyond the edges as 0.
+	PadZero Padding = iota
+	// PadReplicate repeats the nearest entry on the edge.
+	PadReplicate
+	// PadWrap wraps around to the opposite edge, as on a torus.
+	PadWrap
+)
+
+// BoxKernel returns the n×n kernel of ones, whose correlation sums the
+// entries around every entry; dividing by n² blurs.
+func BoxKernel(n int) [][]int {
+	kernel := newMatrix(n, n)
+	for i := range kernel {
+		for j := range kernel[i] {
+			kernel[i][j] = 1
+		}
+	}
+	return kernel
+}
+
+// SobelX returns the Sobel kernel whose correlation is the horizontal
+// gradient, positive where the entries grow to the right.
+func SobelX() [][]int {
+	return [][]int{{-1, 0, 1}, {-2, 0, 2}, {-1, 0, 1}}
+}
+
+// SobelY returns the Sobel kernel whose correlation is the vertical gradient,
+// positive where the entries grow downwards.
+func SobelY() [][]int {
+	return [][]int{{-1, -2, -1}, {0, 0, 0}, {1, 2, 1}}
+}
+
+// Laplacian returns the kernel of the discrete Laplacian over the four
+// neighbors of AreNeighbors.
+func Laplacian() [][]int {
+	return [][]int{{0, 1, 0}, {1, -4, 1}, {0, 1, 0}}
+}
+
+// filter holds the checked arguments of a filter.
+type filter struct {
+	mat, kernel      [][]int
+	rows, cols       int
+	kRows, kCols     int
+	padding          Padding
+	stride           int
+	outRows, outCols int
+}
+
+// newFilter checks the arguments of a filter. If flip is true, the kernel is
+// rotated by 180 degrees, which turns a correlation into a convolution.
+func newFilter(mat, kernel [][]int, padding Padding, stride int, flip bool) (*filter, error) {
+	rows, cols, err := Shape(mat)
+	if err != nil {
+		return nil, err
+	}
+	kRows, kCols, err := Shape(kernel)
+	if err != nil {
+		return nil, fmt.Errorf("kernel: %w", err)
+	}
+	if kRows == 0 || kCols == 0 {
+		return nil, fmt.Errorf("empty kernel: %w", ErrShape)
+	}
+	if stride < 1 {
+		return nil, fmt.Errorf("stride %d: %w", stride, ErrStride)
+	}
+	if padding < PadZero || padding > PadWrap {
+		return nil, fmt.Errorf("padding %d: %w", padding, ErrPadding)
+	}
+	if flip {
+		kernel, _ = Rotate(kernel, 180)
+	}
+	return &filter{
+		mat: mat, kernel: kernel,
+		rows: rows, cols: cols, kRows: kRows, kCols: kCols,
+		padding: padding, stride: stride,
+		outRows: (rows + stride - 1) / stride, outCols: (cols + stride - 1) / stride,
+	}, nil
+}
+
+// at returns the entry (i, j) of the padded matrix.
+func (f *filter) at(i, j int) int {
+	if i >= 0 && i < f.rows && j >= 0 && j < f.cols {
+		return f.mat[i][j]
+	}
+	switch f.padding {
+	case PadReplicate:
+		return f.mat[min(max(i, 0), f.rows-1)][min(max(j, 0), f.cols-1)]
+	case PadWrap:
+		return f.mat[(i%f.rows+f.rows)%f.rows][(j%f.cols+f.cols)%f.cols]
+	}
+	return 0
+}
+
+// direct computes the filter entry by entry, in O(rows·cols·kRows·kCols)
+// time divided by the square of the stride.
+func (f *filter) direct() [][]int {
+	result := newMatrix(f.outRows, f.outCols)
+	top, left := f.kRows/2, f.kCols/2
+	for i := range result {
+		for j := range result[i] {
+			sum := 0
+			for u, row := range f.kernel {
+				for v, k := range row {
+					if k != 0 {
+						sum += k * f.at(i*f.stride+u-top, j*f.stride+v-left)
+					}
+				}
+			}
+			result[i][j] = sum
+		}
+	}
+	return result
+}
+
+// Correlate returns the correlation of mat with kernel: entry (i, j) of the
+// result is the sum of the products of the kernel entries with the entries
+// of mat under them, when the center of the kernel, entry (kRows/2, kCols/2),
+// lies on entry (i·stride, j·stride) of mat. The result has
+// ⌈rows/stride⌉×⌈cols/stride⌉ entries, and padding fills in the entries
+// beyond the edges of mat. It returns ErrStride for a stride below 1,
+// ErrPadding for an unknown padding, ErrShape for an empty kernel and
+// ErrRagged for ragged matrices.
+func Correlate(mat, kernel [][]int, padding Padding, stride int) ([][]int, error) {
+	f, err := newFilter(mat, kernel, padding, stride, false)
+	if err != nil {
+		return nil, err
+	}
+	return f.direct(), nil
+}
+
+// Convolve returns the convolution of mat with kernel, which is the
+// correlation with the kernel rotated by 180 degrees. The arguments and
+// errors are those of Correlate.
+func Convolve(mat, kernel [][]int, padding Padding, stride int) ([][]int, error) {
+	f, err := newFilter(mat, kernel, padding, stride, true)
+	if err != nil {
+		return nil, err
+	}
+	return f.direct(), nil
+}
+
+// CorrelateFFT is like Correlate but multiplies the Fourier transforms of
+// the padded matrix and the kernel, in O(N log N) time for N entries of the
+// padded matrix whatever the size of the kernel, which beats Correlate once
+// the kernel has more than about a hundred entries. It computes in float64
+// and rounds, so the result is exact only while the sums of the absolute
+// values of the products stay well below 2⁵².
+func CorrelateFFT(mat, kernel [][]int, padding Padding, stride int) ([][]int, error) {
+	f, err := newFilter(mat, kernel, padding, stride, false)
+	if err != nil {
+		return nil, err
+	}
+	return f.fft(), nil
+}
+
+// ConvolveFFT is like Convolve but computes as CorrelateFFT does.
+func ConvolveFFT(mat, kernel [][]int, padding Padding, stride int) ([][]int, error) {
+	f, err := newFilter(mat, kernel, padding, stride, true)
+	if err != nil {
+		return nil, err
+	}
+	return f.fft(), nil
+}
+
+// fft computes the filter as the linear convolution of the padded matrix P
+// with the kernel K rotated by 180 degrees, C = P * rot(K), whose entry
+// (i+kRows-1, j+kCols-1) is entry (i, j) of the correlation of P with K.
+func (f *filter) fft() [][]int {
+	result := newMatrix(f.outRows, f.outCols)
+	if f.rows == 0 || f.cols == 0 {
+		return result
+	}
+	top, left := f.kRows/2, f.kCols/2
+	pRows, pCols := f.rows+f.kRows-1, f.cols+f.kCols-1
+	n1 := 1 << bits.Len(uint(pRows+f.kRows-2))
+	n2 := 1 << bits.Len(uint(pCols+f.kCols-2))
+
+	p, k := make([][]complex128, n1), make([][]complex128, n1)
+	for i := range p {
+		p[i], k[i] = make([]complex128, n2), make([]complex128, n2)
+	}
+	for i := 0; i < pRows; i++ {
+		for j := 0; j < pCols; j++ {
+			p[i][j] = complex(float64(f.at(i-top, j-left)), 0)
+		}
+	}
+	for u, row := range f.kernel {
+		for v, x := range row {
+			k[f.kRows-1-u][f.kCols-1-v] = complex(float64(x), 0)
+		}
+	}
+	fft2(p, false)
+	fft2(k, false)
+	for i := range p {
+		for j := range p[i] {
+			p[i][j] *= k[i][j]
+		}
+	}
+	fft2(p, true)
+	for i := range result {
+		for j := range result[i] {
+			result[i][j] = int(math.Round(real(p[i*f.stride+f.kRows-1][j*f.stride+f.kCols-1])))
+		}
+	}
+	return result
+}
+
+// fft2 transforms a, whose sides are powers of 2, in place: every row, then
+// every column. The inverse transform is scaled so that it undoes the
+// forward one.
+func fft2(a [][]complex128, inverse bool) {
+	for _, row := range a {
+		fft(row, inverse)
+	}
+	column := make([]complex128, len(a))
+	for j := range a[0] {
+		for i := range a {
+			column[i] = a[i][j]
+		}
+		fft(column, inverse)
+		for i := range a {
+			a[i][j] = column[i]
+		}
+	}
+}
+
+// fft is the iterative radix-2 Cooley-Tukey transform of a, whose length is
+// a power of 2, in place.
+func fft(a []complex128, inverse bool) {
+	n := len(a)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		angle := 2 * math.Pi / float64(size)
+		if inverse {
+			angle = -angle
+		}
+		root := cmplx.Rect(1, -angle)
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				even, odd := a[start+k], a[start+k+size/2]*w
+				a[start+k], a[start+k+size/2] = even+odd, even-odd
+				w *= root
+			}
+		}
+	}
+	if inverse {
+		for i := range a {
+			a[i] /= complex(float64(n), 0)
+		}
+	}
+}
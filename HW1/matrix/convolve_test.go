@@ -0,0 +1,127 @@
+package matrix
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestCorrelate(t *testing.T) {
+	mat := [][]int{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}
+	tests := []struct {
+		name     string
+		kernel   [][]int
+		padding  Padding
+		stride   int
+		expected [][]int
+	}{
+		{"identity", [][]int{{1}}, PadZero, 1, mat},
+		{"box zero", BoxKernel(3), PadZero, 1, [][]int{{12, 21, 16}, {27, 45, 33}, {24, 39, 28}}},
+		{"box replicate", BoxKernel(3), PadReplicate, 1, [][]int{{21, 27, 33}, {39, 45, 51}, {57, 63, 69}}},
+		{"box wrap", BoxKernel(3), PadWrap, 1, [][]int{{45, 45, 45}, {45, 45, 45}, {45, 45, 45}}},
+		{"box stride", BoxKernel(3), PadZero, 2, [][]int{{12, 16}, {24, 28}}},
+		{"sobel x", SobelX(), PadReplicate, 1, [][]int{{4, 8, 4}, {4, 8, 4}, {4, 8, 4}}},
+		{"sobel y", SobelY(), PadReplicate, 1, [][]int{{12, 12, 12}, {24, 24, 24}, {12, 12, 12}}},
+		{"laplacian", Laplacian(), PadReplicate, 1, [][]int{{4, 3, 2}, {1, 0, -1}, {-2, -3, -4}}},
+		// an even kernel has its center at (0, 0) of its bottom right quarter
+		{"even", [][]int{{1, 0}, {0, 0}}, PadZero, 1, [][]int{{0, 0, 0}, {0, 1, 2}, {0, 4, 5}}},
+		{"shift", [][]int{{0, 0, 0}, {0, 0, 1}, {0, 0, 0}}, PadZero, 1, [][]int{{2, 3, 0}, {5, 6, 0}, {8, 9, 0}}},
+	}
+	for _, test := range tests {
+		for _, correlate := range []struct {
+			name string
+			f    func(mat, kernel [][]int, padding Padding, stride int) ([][]int, error)
+		}{{"Correlate", Correlate}, {"CorrelateFFT", CorrelateFFT}} {
+			actual, err := correlate.f(mat, test.kernel, test.padding, test.stride)
+			if err != nil || !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("%s: %s=%v, %v; want %v", test.name, correlate.name, actual, err, test.expected)
+			}
+		}
+	}
+}
+
+func TestConvolve(t *testing.T) {
+	mat := [][]int{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}
+	// convolving shifts the other way than correlating
+	kernel := [][]int{{0, 0, 0}, {0, 0, 1}, {0, 0, 0}}
+	want := [][]int{{0, 1, 2}, {0, 4, 5}, {0, 7, 8}}
+	for _, convolve := range []struct {
+		name string
+		f    func(mat, kernel [][]int, padding Padding, stride int) ([][]int, error)
+	}{{"Convolve", Convolve}, {"ConvolveFFT", ConvolveFFT}} {
+		if actual, err := convolve.f(mat, kernel, PadZero, 1); err != nil || !reflect.DeepEqual(actual, want) {
+			t.Errorf("%s=%v, %v; want %v", convolve.name, actual, err, want)
+		}
+	}
+	if kernel[1][2] != 1 {
+		t.Errorf("Convolve changed the kernel to %v", kernel)
+	}
+
+	errorTests := []struct {
+		mat, kernel [][]int
+		padding     Padding
+		stride      int
+		err         error
+	}{
+		{mat, BoxKernel(3), PadZero, 0, ErrStride},
+		{mat, [][]int{}, PadZero, 1, ErrShape},
+		{mat, [][]int{{}}, PadZero, 1, ErrShape},
+		{[][]int{{1, 2}, {3}}, BoxKernel(3), PadZero, 1, ErrRagged},
+		{mat, [][]int{{1, 2}, {3}}, PadZero, 1, ErrRagged},
+		{mat, BoxKernel(3), PadWrap + 1, 1, ErrPadding},
+		{mat, BoxKernel(3), -1, 1, ErrPadding},
+	}
+	for i, test := range errorTests {
+		if _, err := Convolve(test.mat, test.kernel, test.padding, test.stride); !errors.Is(err, test.err) {
+			t.Errorf("#%d: Convolve gives %v; want %v", i, err, test.err)
+		}
+		if _, err := Correlate(test.mat, test.kernel, test.padding, test.stride); !errors.Is(err, test.err) {
+			t.Errorf("#%d: Correlate gives %v; want %v", i, err, test.err)
+		}
+		if _, err := ConvolveFFT(test.mat, test.kernel, test.padding, test.stride); !errors.Is(err, test.err) {
+			t.Errorf("#%d: ConvolveFFT gives %v; want %v", i, err, test.err)
+		}
+	}
+	if actual, err := ConvolveFFT([][]int{}, BoxKernel(3), PadWrap, 1); err != nil || len(actual) != 0 {
+		t.Errorf("ConvolveFFT of the empty matrix=%v, %v; want an empty matrix", actual, err)
+	}
+}
+
+// The FFT path agrees with the direct one, for kernels of every shape,
+// larger than the matrix too.
+func TestConvolveFFTRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func(rows, cols int) [][]int {
+		mat := newMatrix(rows, cols)
+		for i := range mat {
+			for j := range mat[i] {
+				mat[i][j] = rng.Intn(2001) - 1000
+			}
+		}
+		return mat
+	}
+	for round := 0; round < 50; round++ {
+		mat := random(1+rng.Intn(20), 1+rng.Intn(20))
+		kernel := random(1+rng.Intn(9), 1+rng.Intn(9))
+		padding, stride := Padding(rng.Intn(3)), 1+rng.Intn(3)
+		want, _ := Convolve(mat, kernel, padding, stride)
+		actual, err := ConvolveFFT(mat, kernel, padding, stride)
+		if err != nil || !reflect.DeepEqual(actual, want) {
+			t.Fatalf("ConvolveFFT of %d×%d with %d×%d, padding %d, stride %d differs from Convolve",
+				len(mat), len(mat[0]), len(kernel), len(kernel[0]), padding, stride)
+		}
+	}
+}
+
+func benchmarkConvolve(b *testing.B, convolve func(mat, kernel [][]int, padding Padding, stride int) ([][]int, error)) {
+	mat := newMatrix(128, 128)
+	kernel := BoxKernel(15)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		convolve(mat, kernel, PadReplicate, 1)
+	}
+}
+
+func BenchmarkConvolve(b *testing.B)    { benchmarkConvolve(b, Convolve) }
+func BenchmarkConvolveFFT(b *testing.B) { benchmarkConvolve(b, ConvolveFFT) }
@@ -0,0 +1,473 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrSingular is returned when a matrix is singular, or so close to singular
+// that the result would be meaningless, and for least squares problems whose
+// columns are linearly dependent.
+var ErrSingular = errors.New("matrix is singular")
+
+// ErrNotPositiveDefinite is returned by NewCholesky for a matrix that is not
+// symmetric positive definite.
+var ErrNotPositiveDefinite = errors.New("matrix is not symmetric positive definite")
+
+// copyFloat returns a copy of mat whose rows share one backing array.
+func copyFloat(mat [][]float64, rows, cols int) [][]float64 {
+	result := make([][]float64, rows)
+	backing := make([]float64, rows*cols)
+	for i := range result {
+		result[i] = backing[i*cols : (i+1)*cols : (i+1)*cols]
+		copy(result[i], mat[i])
+	}
+	return result
+}
+
+// tolerance returns the size below which a pivot of the rows×cols matrix mat
+// counts as zero: the rounding error the elimination may have made.
+func tolerance(mat [][]float64, rows, cols int) float64 {
+	largest := 0.0
+	for _, row := range mat {
+		for _, x := range row {
+			largest = math.Max(largest, math.Abs(x))
+		}
+	}
+	return float64(max(rows, cols)) * largest * 0x1p-52
+}
+
+// square returns the size of mat, or an error if it is ragged or not square.
+func square(mat [][]float64, op string) (int, error) {
+	rows, cols, err := shapeOf(mat)
+	if err != nil {
+		return 0, err
+	}
+	if rows != cols {
+		return 0, fmt.Errorf("%s of %d×%d: %w", op, rows, cols, ErrShape)
+	}
+	return rows, nil
+}
+
+// LU is the LU decomposition with partial pivoting of a square matrix A:
+// PA = LU for a permutation P, a unit lower triangular L and an upper
+// triangular U.
+type LU struct {
+	// lu holds L below the diagonal, without its ones, and U on and above it
+	lu [][]float64
+	// pivot[i] is the row of A that is row i of PA
+	pivot []int
+	sign  float64
+	norm1 float64
+}
+
+// NewLU returns the LU decomposition of a, choosing the largest pivot of every
+// column. It returns ErrSingular if a is singular, ErrShape if it is not
+// square and ErrRagged if it is ragged.
+func NewLU(a [][]float64) (*LU, error) {
+	n, err := square(a, "LU decomposition")
+	if err != nil {
+		return nil, err
+	}
+	d := &LU{lu: copyFloat(a, n, n), pivot: make([]int, n), sign: 1, norm1: norm1(a)}
+	lu := d.lu
+	for i := range d.pivot {
+		d.pivot[i] = i
+	}
+	tol := tolerance(a, n, n)
+	for k := 0; k < n; k++ {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(lu[i][k]) > math.Abs(lu[p][k]) {
+				p = i
+			}
+		}
+		if math.Abs(lu[p][k]) <= tol {
+			return nil, fmt.Errorf("no pivot in column %d: %w", k+1, ErrSingular)
+		}
+		if p != k {
+			lu[p], lu[k] = lu[k], lu[p]
+			d.pivot[p], d.pivot[k] = d.pivot[k], d.pivot[p]
+			d.sign = -d.sign
+		}
+		for i := k + 1; i < n; i++ {
+			lu[i][k] /= lu[k][k]
+			for j := k + 1; j < n; j++ {
+				lu[i][j] -= lu[i][k] * lu[k][j]
+			}
+		}
+	}
+	return d, nil
+}
+
+// L returns the unit lower triangular factor.
+func (d *LU) L() [][]float64 {
+	n := len(d.lu)
+	l := copyFloat(make([][]float64, n), n, n)
+	for i := range l {
+		copy(l[i], d.lu[i][:i])
+		l[i][i] = 1
+	}
+	return l
+}
+
+// U returns the upper triangular factor.
+func (d *LU) U() [][]float64 {
+	n := len(d.lu)
+	u := copyFloat(make([][]float64, n), n, n)
+	for i := range u {
+		copy(u[i][i:], d.lu[i][i:])
+	}
+	return u
+}
+
+// Pivot returns the permutation P as the rows of A in the order of PA.
+func (d *LU) Pivot() []int {
+	return append([]int(nil), d.pivot...)
+}
+
+// Det returns the determinant of A.
+func (d *LU) Det() float64 {
+	det := d.sign
+	for i := range d.lu {
+		det *= d.lu[i][i]
+	}
+	return det
+}
+
+// Solve returns the solution x of Ax = b. It panics if b is not as long as A
+// is wide.
+func (d *LU) Solve(b []float64) []float64 {
+	n := len(d.lu)
+	if len(b) != n {
+		panic(fmt.Sprintf("matrix: solving %d×%d with %d values", n, n, len(b)))
+	}
+	x := make([]float64, n)
+	for i, p := range d.pivot {
+		x[i] = b[p]
+	}
+	for i := range x {
+		for j := 0; j < i; j++ {
+			x[i] -= d.lu[i][j] * x[j]
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := i + 1; j < n; j++ {
+			x[i] -= d.lu[i][j] * x[j]
+		}
+		x[i] /= d.lu[i][i]
+	}
+	return x
+}
+
+// solveTranspose returns the solution x of Aᵀx = c, where Aᵀ = UᵀLᵀP.
+func (d *LU) solveTranspose(c []float64) []float64 {
+	n := len(d.lu)
+	v := append([]float64(nil), c...)
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			v[i] -= d.lu[j][i] * v[j]
+		}
+		v[i] /= d.lu[i][i]
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := i + 1; j < n; j++ {
+			v[i] -= d.lu[j][i] * v[j]
+		}
+	}
+	x := make([]float64, n)
+	for i, p := range d.pivot {
+		x[p] = v[i]
+	}
+	return x
+}
+
+// Inverse returns the inverse of A.
+func (d *LU) Inverse() [][]float64 {
+	n := len(d.lu)
+	inv := copyFloat(make([][]float64, n), n, n)
+	e := make([]float64, n)
+	for j := 0; j < n; j++ {
+		e[j] = 1
+		for i, x := range d.Solve(e) {
+			inv[i][j] = x
+		}
+		e[j] = 0
+	}
+	return inv
+}
+
+// Cond1 returns an estimate of the condition number of A in the 1-norm,
+// ‖A‖₁‖A⁻¹‖₁, with Hager's method: it finds ‖A⁻¹‖₁ from a few solves
+// instead of the inverse, and the estimate is never too large and rarely much
+// too small. The larger it is, the more digits a solution may lose.
+func (d *LU) Cond1() float64 {
+	n := len(d.lu)
+	if n == 0 {
+		return 0
+	}
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = 1 / float64(n)
+	}
+	estimate := 0.0
+	for iteration := 0; iteration < 5; iteration++ {
+		y := d.Solve(x)
+		estimate = 0
+		sign := make([]float64, n)
+		for i, yi := range y {
+			estimate += math.Abs(yi)
+			sign[i] = 1
+			if yi < 0 {
+				sign[i] = -1
+			}
+		}
+		z := d.solveTranspose(sign)
+		best, zx := 0, 0.0
+		for i, zi := range z {
+			zx += zi * x[i]
+			if math.Abs(zi) > math.Abs(z[best]) {
+				best = i
+			}
+		}
+		if math.Abs(z[best]) <= zx {
+			break
+		}
+		clear(x)
+		x[best] = 1
+	}
+	return d.norm1 * estimate
+}
+
+// norm1 returns the 1-norm of mat, the largest sum of the absolute values of
+// a column.
+func norm1(mat [][]float64) float64 {
+	var sums []float64
+	for _, row := range mat {
+		for j, x := range row {
+			if j == len(sums) {
+				sums = append(sums, 0)
+			}
+			sums[j] += math.Abs(x)
+		}
+	}
+	largest := 0.0
+	for _, sum := range sums {
+		largest = math.Max(largest, sum)
+	}
+	return largest
+}
+
+// QR is the QR decomposition of an m×n matrix A with m ≥ n: A = QR for an m×n
+// matrix Q with orthonormal columns and an upper triangular n×n matrix R.
+type QR struct {
+	// qr holds the Householder vectors on and below the diagonal and R above
+	qr    [][]float64
+	rdiag []float64
+	tol   float64
+}
+
+// NewQR returns the QR decomposition of a computed with Householder
+// reflections. It returns ErrShape if a has fewer rows than columns and
+// ErrRagged if it is ragged.
+func NewQR(a [][]float64) (*QR, error) {
+	m, n, err := shapeOf(a)
+	if err != nil {
+		return nil, err
+	}
+	if m < n {
+		return nil, fmt.Errorf("QR decomposition of %d×%d: %w", m, n, ErrShape)
+	}
+	d := &QR{qr: copyFloat(a, m, n), rdiag: make([]float64, n), tol: tolerance(a, m, n)}
+	qr := d.qr
+	for k := 0; k < n; k++ {
+		norm := 0.0
+		for i := k; i < m; i++ {
+			norm = math.Hypot(norm, qr[i][k])
+		}
+		if norm != 0 {
+			if qr[k][k] < 0 {
+				norm = -norm
+			}
+			for i := k; i < m; i++ {
+				qr[i][k] /= norm
+			}
+			qr[k][k]++
+			for j := k + 1; j < n; j++ {
+				s := 0.0
+				for i := k; i < m; i++ {
+					s += qr[i][k] * qr[i][j]
+				}
+				s = -s / qr[k][k]
+				for i := k; i < m; i++ {
+					qr[i][j] += s * qr[i][k]
+				}
+			}
+		}
+		d.rdiag[k] = -norm
+	}
+	return d, nil
+}
+
+// FullRank reports whether the columns of A are linearly independent, that
+// is, whether R has no zero on its diagonal.
+func (d *QR) FullRank() bool {
+	for _, r := range d.rdiag {
+		if math.Abs(r) <= d.tol {
+			return false
+		}
+	}
+	return true
+}
+
+// Q returns the m×n factor with orthonormal columns.
+func (d *QR) Q() [][]float64 {
+	m, n := len(d.qr), len(d.rdiag)
+	q := copyFloat(make([][]float64, m), m, n)
+	for k := n - 1; k >= 0; k-- {
+		q[k][k] = 1
+		for j := k; j < n; j++ {
+			if d.qr[k][k] == 0 {
+				continue
+			}
+			s := 0.0
+			for i := k; i < m; i++ {
+				s += d.qr[i][k] * q[i][j]
+			}
+			s = -s / d.qr[k][k]
+			for i := k; i < m; i++ {
+				q[i][j] += s * d.qr[i][k]
+			}
+		}
+	}
+	return q
+}
+
+// R returns the n×n upper triangular factor.
+func (d *QR) R() [][]float64 {
+	n := len(d.rdiag)
+	r := copyFloat(make([][]float64, n), n, n)
+	for i := range r {
+		r[i][i] = d.rdiag[i]
+		copy(r[i][i+1:], d.qr[i][i+1:])
+	}
+	return r
+}
+
+// Solve returns the least squares solution x of Ax = b, which minimizes
+// ‖Ax - b‖₂, and is the exact solution if there is one. It returns
+// ErrSingular if the columns of A are linearly dependent. It panics if b is
+// not as long as A is high.
+func (d *QR) Solve(b []float64) ([]float64, error) {
+	m, n := len(d.qr), len(d.rdiag)
+	if len(b) != m {
+		panic(fmt.Sprintf("matrix: solving %d×%d with %d values", m, n, len(b)))
+	}
+	if !d.FullRank() {
+		return nil, fmt.Errorf("least squares with dependent columns: %w", ErrSingular)
+	}
+	// x = Qᵀb, then solve Rx = Qᵀb
+	x := append([]float64(nil), b...)
+	for k := 0; k < n; k++ {
+		s := 0.0
+		for i := k; i < m; i++ {
+			s += d.qr[i][k] * x[i]
+		}
+		s = -s / d.qr[k][k]
+		for i := k; i < m; i++ {
+			x[i] += s * d.qr[i][k]
+		}
+	}
+	for k := n - 1; k >= 0; k-- {
+		x[k] /= d.rdiag[k]
+		for i := 0; i < k; i++ {
+			x[i] -= x[k] * d.qr[i][k]
+		}
+	}
+	return x[:n], nil
+}
+
+// LeastSquares returns the x that minimizes ‖ax - b‖₂ for an m×n matrix a
+// with m ≥ n, using its QR decomposition. It returns ErrSingular if the
+// columns of a are linearly dependent, ErrShape if a has fewer rows than
+// columns or b is not as long as a is high, and ErrRagged if a is ragged.
+func LeastSquares(a [][]float64, b []float64) ([]float64, error) {
+	d, err := NewQR(a)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != len(a) {
+		return nil, fmt.Errorf("least squares of %d rows with %d values: %w", len(a), len(b), ErrShape)
+	}
+	return d.Solve(b)
+}
+
+// Cholesky is the Cholesky decomposition of a symmetric positive definite
+// matrix A: A = LLᵀ for a lower triangular L with a positive diagonal.
+type Cholesky struct {
+	l [][]float64
+}
+
+// NewCholesky returns the Cholesky decomposition of a. It returns
+// ErrNotPositiveDefinite if a is not symmetric positive definite, ErrShape if
+// it is not square and ErrRagged if it is ragged.
+func NewCholesky(a [][]float64) (*Cholesky, error) {
+	n, err := square(a, "Cholesky decomposition")
+	if err != nil {
+		return nil, err
+	}
+	tol := tolerance(a, n, n)
+	l := copyFloat(make([][]float64, n), n, n)
+	for j := 0; j < n; j++ {
+		d := a[j][j]
+		for k := 0; k < j; k++ {
+			d -= l[j][k] * l[j][k]
+		}
+		if d <= tol {
+			return nil, fmt.Errorf("pivot %g in column %d: %w", d, j+1, ErrNotPositiveDefinite)
+		}
+		l[j][j] = math.Sqrt(d)
+		for i := j + 1; i < n; i++ {
+			if math.Abs(a[i][j]-a[j][i]) > tol {
+				return nil, fmt.Errorf("entries (%d, %d) and (%d, %d) differ: %w",
+					i+1, j+1, j+1, i+1, ErrNotPositiveDefinite)
+			}
+			s := a[i][j]
+			for k := 0; k < j; k++ {
+				s -= l[i][k] * l[j][k]
+			}
+			l[i][j] = s / l[j][j]
+		}
+	}
+	return &Cholesky{l}, nil
+}
+
+// L returns the lower triangular factor.
+func (d *Cholesky) L() [][]float64 {
+	n := len(d.l)
+	return copyFloat(d.l, n, n)
+}
+
+// Solve returns the solution x of Ax = b. It panics if b is not as long as A
+// is wide.
+func (d *Cholesky) Solve(b []float64) []float64 {
+	n := len(d.l)
+	if len(b) != n {
+		panic(fmt.Sprintf("matrix: solving %d×%d with %d values", n, n, len(b)))
+	}
+	x := append([]float64(nil), b...)
+	for i := 0; i < n; i++ {
+		for k := 0; k < i; k++ {
+			x[i] -= d.l[i][k] * x[k]
+		}
+		x[i] /= d.l[i][i]
+	}
+	for i := n - 1; i >= 0; i-- {
+		for k := i + 1; k < n; k++ {
+			x[i] -= d.l[k][i] * x[k]
+		}
+		x[i] /= d.l[i][i]
+	}
+	return x
+}
@@ -0,0 +1,241 @@
+package matrix
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// multiplyFloat returns the product of a and b.
+func multiplyFloat(a, b [][]float64) [][]float64 {
+	product := make([][]float64, len(a))
+	for i := range product {
+		product[i] = make([]float64, len(b[0]))
+		for k := range b {
+			for j := range b[k] {
+				product[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return product
+}
+
+// transposeFloat returns the transpose of a.
+func transposeFloat(a [][]float64) [][]float64 {
+	result := make([][]float64, len(a[0]))
+	for j := range result {
+		result[j] = make([]float64, len(a))
+		for i := range a {
+			result[j][i] = a[i][j]
+		}
+	}
+	return result
+}
+
+// closeMatrix reports whether a and b have the same shape and agree to about
+// eight digits.
+func closeMatrix(a, b [][]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !closeVector(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func closeVector(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-8*math.Max(1, math.Abs(b[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func randomFloat(rng *rand.Rand, rows, cols int) [][]float64 {
+	mat := make([][]float64, rows)
+	for i := range mat {
+		mat[i] = make([]float64, cols)
+		for j := range mat[i] {
+			mat[i][j] = rng.Float64()*2 - 1
+		}
+	}
+	return mat
+}
+
+func TestLU(t *testing.T) {
+	a := [][]float64{{2, 1, 1}, {4, -6, 0}, {-2, 7, 2}}
+	d, err := NewLU(a)
+	if err != nil {
+		t.Fatalf("NewLU(%v) gives %v", a, err)
+	}
+	pa := make([][]float64, len(a))
+	for i, p := range d.Pivot() {
+		pa[i] = a[p]
+	}
+	if lu := multiplyFloat(d.L(), d.U()); !closeMatrix(lu, pa) {
+		t.Errorf("LU=%v; want PA=%v", lu, pa)
+	}
+	if det := d.Det(); math.Abs(det-(-16)) > 1e-12 {
+		t.Errorf("Det()=%v; want -16", det)
+	}
+	if x := d.Solve([]float64{5, -2, 9}); !closeVector(x, []float64{1, 1, 2}) {
+		t.Errorf("Solve=%v; want [1 1 2]", x)
+	}
+	identity := [][]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	if product := multiplyFloat(a, d.Inverse()); !closeMatrix(product, identity) {
+		t.Errorf("A times Inverse()=%v; want the identity", product)
+	}
+
+	errorTests := []struct {
+		a   [][]float64
+		err error
+	}{
+		{[][]float64{{1, 2}, {2, 4}}, ErrSingular},
+		{[][]float64{{0, 0}, {0, 0}}, ErrSingular},
+		{[][]float64{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}, ErrSingular},
+		{[][]float64{{1, 2}}, ErrShape},
+		{[][]float64{{1, 2}, {3}}, ErrRagged},
+	}
+	for i, test := range errorTests {
+		if _, err := NewLU(test.a); !errors.Is(err, test.err) {
+			t.Errorf("#%d: NewLU(%v) gives %v; want %v", i, test.a, err, test.err)
+		}
+	}
+}
+
+// Hager's estimate is a lower bound of the exact condition number, and for
+// random matrices close to it.
+func TestCond1(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for round := 0; round < 20; round++ {
+		n := 1 + rng.Intn(8)
+		a := randomFloat(rng, n, n)
+		d, err := NewLU(a)
+		if err != nil {
+			t.Fatalf("NewLU gives %v", err)
+		}
+		exact := norm1(a) * norm1(d.Inverse())
+		if estimate := d.Cond1(); estimate > exact*(1+1e-9) || estimate < exact/3 {
+			t.Errorf("Cond1()=%v; the exact condition number is %v", estimate, exact)
+		}
+	}
+
+	// the Hilbert matrices are famously ill-conditioned
+	hilbert := make([][]float64, 8)
+	for i := range hilbert {
+		hilbert[i] = make([]float64, 8)
+		for j := range hilbert[i] {
+			hilbert[i][j] = 1 / float64(i+j+1)
+		}
+	}
+	d, err := NewLU(hilbert)
+	if err != nil {
+		t.Fatalf("NewLU(hilbert) gives %v", err)
+	}
+	if cond := d.Cond1(); cond < 1e10 {
+		t.Errorf("Cond1() of the 8×8 Hilbert matrix=%v; want above 1e10", cond)
+	}
+	if d, _ := NewLU([][]float64{}); d.Cond1() != 0 {
+		t.Errorf("Cond1() of the empty matrix=%v; want 0", d.Cond1())
+	}
+}
+
+func TestQR(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range [][2]int{{1, 1}, {3, 3}, {5, 2}, {8, 5}} {
+		a := randomFloat(rng, size[0], size[1])
+		d, err := NewQR(a)
+		if err != nil {
+			t.Fatalf("NewQR gives %v", err)
+		}
+		q, r := d.Q(), d.R()
+		if qr := multiplyFloat(q, r); !closeMatrix(qr, a) {
+			t.Errorf("QR=%v; want %v", qr, a)
+		}
+		identity := make([][]float64, size[1])
+		for i := range identity {
+			identity[i] = make([]float64, size[1])
+			identity[i][i] = 1
+		}
+		if qtq := multiplyFloat(transposeFloat(q), q); !closeMatrix(qtq, identity) {
+			t.Errorf("QᵀQ=%v; want the identity", qtq)
+		}
+		for i := range r {
+			for j := 0; j < i; j++ {
+				if r[i][j] != 0 {
+					t.Errorf("R=%v is not upper triangular", r)
+				}
+			}
+		}
+	}
+
+	if _, err := NewQR([][]float64{{1, 2}}); !errors.Is(err, ErrShape) {
+		t.Errorf("NewQR of 1×2 gives %v; want ErrShape", err)
+	}
+	d, _ := NewQR([][]float64{{1, 2}, {2, 4}, {3, 6}})
+	if _, err := d.Solve([]float64{1, 2, 3}); !errors.Is(err, ErrSingular) || d.FullRank() {
+		t.Errorf("Solve with dependent columns gives %v; want ErrSingular", err)
+	}
+}
+
+func TestLeastSquares(t *testing.T) {
+	// the line through (0, 1), (1, 3), (2, 5) exactly, and the best line
+	// through (0, 0), (1, 1), (2, 1) by the normal equations
+	tests := []struct {
+		a        [][]float64
+		b        []float64
+		expected []float64
+	}{
+		{[][]float64{{1, 0}, {1, 1}, {1, 2}}, []float64{1, 3, 5}, []float64{1, 2}},
+		{[][]float64{{1, 0}, {1, 1}, {1, 2}}, []float64{0, 1, 1}, []float64{1.0 / 6, 0.5}},
+		{[][]float64{{2, 0}, {0, 3}}, []float64{4, 9}, []float64{2, 3}},
+	}
+	for i, test := range tests {
+		x, err := LeastSquares(test.a, test.b)
+		if err != nil || !closeVector(x, test.expected) {
+			t.Errorf("#%d: LeastSquares(%v, %v)=%v, %v; want %v", i, test.a, test.b, x, err, test.expected)
+		}
+	}
+	if _, err := LeastSquares([][]float64{{1}, {2}}, []float64{1}); !errors.Is(err, ErrShape) {
+		t.Errorf("LeastSquares with a short b gives %v; want ErrShape", err)
+	}
+}
+
+func TestCholesky(t *testing.T) {
+	a := [][]float64{{4, 12, -16}, {12, 37, -43}, {-16, -43, 98}}
+	d, err := NewCholesky(a)
+	if err != nil {
+		t.Fatalf("NewCholesky(%v) gives %v", a, err)
+	}
+	want := [][]float64{{2, 0, 0}, {6, 1, 0}, {-8, 5, 3}}
+	if l := d.L(); !closeMatrix(l, want) {
+		t.Errorf("L()=%v; want %v", l, want)
+	}
+	b := []float64{-12, -31, 82}
+	if x := d.Solve(b); !closeVector(x, []float64{1, 0, 1}) {
+		t.Errorf("Solve(%v)=%v; want [1 0 1]", b, x)
+	}
+
+	errorTests := []struct {
+		a   [][]float64
+		err error
+	}{
+		{[][]float64{{1, 2}, {2, 1}}, ErrNotPositiveDefinite},
+		{[][]float64{{0, 0}, {0, 1}}, ErrNotPositiveDefinite},
+		{[][]float64{{2, 1}, {0, 2}}, ErrNotPositiveDefinite},
+		{[][]float64{{1, 2}}, ErrShape},
+	}
+	for i, test := range errorTests {
+		if _, err := NewCholesky(test.a); !errors.Is(err, test.err) {
+			t.Errorf("#%d: NewCholesky(%v) gives %v; want %v", i, test.a, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package matrix
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrRagged is returned when the rows of a matrix do not all have the same
+// length.
+var ErrRagged = errors.New("rows of different lengths")
+
+// ErrSyntax is returned, wrapped with the line it is on, when the input is
+// not in the format being read.
+var ErrSyntax = errors.New("syntax error")
+
+// ErrTooLarge is returned by the readers that allocate a matrix from the
+// size an input gives, before the entries, when the size is above MaxEntries.
+var ErrTooLarge = errors.New("matrix too large")
+
+// MaxEntries is the largest number of entries, and of rows or columns, that
+// ReadMatrixMarket and ReadRLE allocate for a size given in their input. It
+// keeps a hostile or mistaken size line from exhausting memory; raise it to
+// read larger matrices.
+var MaxEntries = 1 << 26
+
+// checkSize returns ErrTooLarge if a rows×cols matrix is beyond MaxEntries.
+func checkSize(rows, cols int) error {
+	if rows > MaxEntries || cols > MaxEntries || rows*cols > MaxEntries {
+		return fmt.Errorf("%d×%d is above %d entries: %w", rows, cols, MaxEntries, ErrTooLarge)
+	}
+	return nil
+}
+
+// checkRows returns ErrRagged unless every row of mat is as long as the first.
+func checkRows[T any](mat [][]T) error {
+	for i := range mat {
+		if len(mat[i]) != len(mat[0]) {
+			return fmt.Errorf("row %d has %d entries, row 1 has %d: %w",
+				i+1, len(mat[i]), len(mat[0]), ErrRagged)
+		}
+	}
+	return nil
+}
+
+// maxLine is the longest line ReadText and ReadMatrixMarket read, far beyond
+// the 64 KiB that bufio.Scanner allows by default, so that long rows fit.
+const maxLine = 1 << 30
+
+// newLineScanner returns a scanner of the lines of r up to maxLine bytes long.
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLine)
+	return scanner
+}
+
+// ReadText reads a matrix of whitespace-separated integers, one row per line.
+// Blank lines are skipped, so an input without numbers is the empty matrix.
+func ReadText(r io.Reader) ([][]int, error) {
+	mat := [][]int{}
+	scanner := newLineScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		row := make([]int, len(fields))
+		for j, field := range fields {
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %q is not an integer: %w", line, field, ErrSyntax)
+			}
+			row[j] = n
+		}
+		if len(mat) > 0 && len(row) != len(mat[0]) {
+			return nil, fmt.Errorf("line %d has %d entries, the first row has %d: %w",
+				line, len(row), len(mat[0]), ErrRagged)
+		}
+		mat = append(mat, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return mat, nil
+}
+
+// WriteText writes mat as ReadText reads it: a line per row, with the entries
+// separated by single spaces.
+func WriteText(w io.Writer, mat [][]int) error {
+	if err := checkRows(mat); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	for _, row := range mat {
+		for j, n := range row {
+			if j > 0 {
+				bw.WriteByte(' ')
+			}
+			bw.WriteString(strconv.Itoa(n))
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ReadCSV reads a matrix of integers in CSV, one row per record. Spaces
+// around the numbers are allowed.
+func ReadCSV(r io.Reader) ([][]int, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	mat := [][]int{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return mat, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount) {
+			return nil, fmt.Errorf("line %d has %d entries, the first row has %d: %w",
+				parseErr.StartLine, len(record), len(mat[0]), ErrRagged)
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		row := make([]int, len(record))
+		for j, field := range record {
+			n, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %q is not an integer: %w", line, field, ErrSyntax)
+			}
+			row[j] = n
+		}
+		mat = append(mat, row)
+	}
+}
+
+// WriteCSV writes mat in CSV, one record per row.
+func WriteCSV(w io.Writer, mat [][]int) error {
+	if err := checkRows(mat); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	for _, row := range mat {
+		record := make([]string, len(row))
+		for j, n := range row {
+			record[j] = strconv.Itoa(n)
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ReadMatrixMarket reads a matrix in the MatrixMarket coordinate format: a
+// header line such as
+//
+//	%%MatrixMarket matrix coordinate integer general
+//
+// then comment lines starting with %, a line with the numbers of rows,
+// columns and entries, and a line "i j value" for every entry, counting rows
+// and columns from 1. The field may be integer, or pattern, whose entries have
+// no value and read as 1. The symmetry may be general, symmetric or
+// skew-symmetric, in which case only the entries on and below the diagonal are
+// given. Entries not given are 0. As the matrix is dense, it returns
+// ErrTooLarge for a size above MaxEntries.
+func ReadMatrixMarket(r io.Reader) ([][]int, error) {
+	scanner := newLineScanner(r)
+	line := 0
+	// next returns the fields of the next line that is not a comment
+	next := func() ([]string, bool) {
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text != "" && !strings.HasPrefix(text, "%") {
+				return strings.Fields(text), true
+			}
+		}
+		return nil, false
+	}
+	syntax := func(format string, args ...any) error {
+		return fmt.Errorf("line %d: %s: %w", line, fmt.Sprintf(format, args...), ErrSyntax)
+	}
+	atoi := func(field string) (int, error) {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return 0, syntax("%q is not an integer", field)
+		}
+		return n, nil
+	}
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no header: %w", ErrSyntax)
+	}
+	line++
+	header := strings.Fields(strings.ToLower(scanner.Text()))
+	if len(header) != 5 || header[0] != "%%matrixmarket" || header[1] != "matrix" || header[2] != "coordinate" {
+		return nil, syntax("not a MatrixMarket coordinate header")
+	}
+	field, symmetry := header[3], header[4]
+	if field != "integer" && field != "pattern" {
+		return nil, syntax("unsupported field %q", field)
+	}
+	if symmetry != "general" && symmetry != "symmetric" && symmetry != "skew-symmetric" {
+		return nil, syntax("unsupported symmetry %q", symmetry)
+	}
+
+	size, ok := next()
+	if !ok {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no size line: %w", ErrSyntax)
+	}
+	if len(size) != 3 {
+		return nil, syntax("the size line has %d fields, not 3", len(size))
+	}
+	var dims [3]int
+	for k := range dims {
+		n, err := atoi(size[k])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, syntax("negative size %d", n)
+		}
+		dims[k] = n
+	}
+	rows, cols, entries := dims[0], dims[1], dims[2]
+	if symmetry != "general" && rows != cols {
+		return nil, syntax("a %s matrix of %d×%d is not square", symmetry, rows, cols)
+	}
+	if err := checkSize(rows, cols); err != nil {
+		return nil, err
+	}
+
+	mat := make([][]int, rows)
+	for i := range mat {
+		mat[i] = make([]int, cols)
+	}
+	given := map[[2]int]bool{}
+	for k := 0; k < entries; k++ {
+		fields, ok := next()
+		if !ok {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%d of %d entries: %w", k, entries, io.ErrUnexpectedEOF)
+		}
+		want := 3
+		if field == "pattern" {
+			want = 2
+		}
+		if len(fields) != want {
+			return nil, syntax("an entry has %d fields, not %d", len(fields), want)
+		}
+		i, err := atoi(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		j, err := atoi(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		value := 1
+		if field == "integer" {
+			if value, err = atoi(fields[2]); err != nil {
+				return nil, err
+			}
+		}
+		if i < 1 || i > rows || j < 1 || j > cols {
+			return nil, syntax("entry (%d, %d) outside %d×%d", i, j, rows, cols)
+		}
+		if symmetry != "general" && j > i {
+			return nil, syntax("entry (%d, %d) above the diagonal of a %s matrix", i, j, symmetry)
+		}
+		if symmetry == "skew-symmetric" && i == j && value != 0 {
+			return nil, syntax("entry (%d, %d) on the diagonal of a skew-symmetric matrix", i, j)
+		}
+		if given[[2]int{i, j}] {
+			return nil, syntax("entry (%d, %d) given twice", i, j)
+		}
+		given[[2]int{i, j}] = true
+		mat[i-1][j-1] = value
+		switch symmetry {
+		case "symmetric":
+			mat[j-1][i-1] = value
+		case "skew-symmetric":
+			mat[j-1][i-1] = -value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return mat, nil
+}
+
+// WriteMatrixMarket writes mat in the MatrixMarket coordinate format as a
+// general integer matrix, with an entry for every entry that is not 0.
+func WriteMatrixMarket(w io.Writer, mat [][]int) error {
+	if err := checkRows(mat); err != nil {
+		return err
+	}
+	cols := 0
+	if len(mat) > 0 {
+		cols = len(mat[0])
+	}
+	entries := 0
+	for _, row := range mat {
+		for _, n := range row {
+			if n != 0 {
+				entries++
+			}
+		}
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "%%MatrixMarket matrix coordinate integer general")
+	fmt.Fprintln(bw, len(mat), cols, entries)
+	for i, row := range mat {
+		for j, n := range row {
+			if n != 0 {
+				fmt.Fprintln(bw, i+1, j+1, n)
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadJSON reads a matrix as a JSON array of arrays of integers, such as
+// [[1,2],[3,4]].
+func ReadJSON(r io.Reader) ([][]int, error) {
+	var mat [][]int
+	if err := json.NewDecoder(r).Decode(&mat); err != nil {
+		return nil, err
+	}
+	if mat == nil {
+		mat = [][]int{}
+	}
+	for i := range mat {
+		if mat[i] == nil {
+			mat[i] = []int{}
+		}
+	}
+	if err := checkRows(mat); err != nil {
+		return nil, err
+	}
+	return mat, nil
+}
+
+// WriteJSON writes mat as a JSON array of arrays, a row per line.
+func WriteJSON(w io.Writer, mat [][]int) error {
+	if err := checkRows(mat); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('[')
+	for i, row := range mat {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		bw.WriteString("\n  [")
+		for j, n := range row {
+			if j > 0 {
+				bw.WriteByte(',')
+			}
+			bw.WriteString(strconv.Itoa(n))
+		}
+		bw.WriteByte(']')
+	}
+	if len(mat) > 0 {
+		bw.WriteByte('\n')
+	}
+	bw.WriteString("]\n")
+	return bw.Flush()
+}
+
+// Pretty returns mat as a table with the entries of every column aligned to
+// the right, for reading and for diffing in test failures. If rowHeaders or
+// colHeaders is not nil, it labels the rows or the columns; missing labels are
+// left blank. A ragged mat is printed as it is.
+func Pretty(mat [][]int, rowHeaders, colHeaders []string) string {
+	// cells holds the text of the table, headers included
+	var cells [][]string
+	label := func(labels []string, i int) string {
+		if i < len(labels) {
+			return labels[i]
+		}
+		return ""
+	}
+	cols := 0
+	for _, row := range mat {
+		cols = max(cols, len(row))
+	}
+	if colHeaders != nil {
+		var header []string
+		if rowHeaders != nil {
+			header = append(header, "")
+		}
+		for j := 0; j < max(cols, len(colHeaders)); j++ {
+			header = append(header, label(colHeaders, j))
+		}
+		cells = append(cells, header)
+	}
+	for i, row := range mat {
+		var line []string
+		if rowHeaders != nil {
+			line = append(line, label(rowHeaders, i))
+		}
+		for _, n := range row {
+			line = append(line, strconv.Itoa(n))
+		}
+		cells = append(cells, line)
+	}
+
+	var width []int
+	for _, line := range cells {
+		for j, cell := range line {
+			if j == len(width) {
+				width = append(width, 0)
+			}
+			width[j] = max(width[j], len([]rune(cell)))
+		}
+	}
+	var b strings.Builder
+	for _, line := range cells {
+		for j, cell := range line {
+			if j > 0 {
+				b.WriteByte(' ')
+			}
+			pad := width[j] - len([]rune(cell))
+			if rowHeaders != nil && j == 0 {
+				// row labels are aligned to the left
+				b.WriteString(cell)
+				b.WriteString(strings.Repeat(" ", pad))
+			} else {
+				b.WriteString(strings.Repeat(" ", pad))
+				b.WriteString(cell)
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,194 @@
+package matrix
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// formats are the readers and writers of every format, for round trips.
+var formats = []struct {
+	name  string
+	read  func(io.Reader) ([][]int, error)
+	write func(io.Writer, [][]int) error
+}{
+	{"text", ReadText, WriteText},
+	{"CSV", ReadCSV, WriteCSV},
+	{"MatrixMarket", ReadMatrixMarket, WriteMatrixMarket},
+	{"JSON", ReadJSON, WriteJSON},
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := [][][]int{
+		{{1, 2, 3}, {4, 5, 6}},
+		{{-7}},
+		{{0, 0}, {0, -12}, {300, 0}},
+		{{1}, {2}, {3}},
+	}
+	for _, format := range formats {
+		for i, mat := range tests {
+			var buf bytes.Buffer
+			if err := format.write(&buf, mat); err != nil {
+				t.Errorf("#%d: %s: writing %v gives %v", i, format.name, mat, err)
+				continue
+			}
+			actual, err := format.read(&buf)
+			if err != nil || !reflect.DeepEqual(actual, mat) {
+				t.Errorf("#%d: %s: round trip of %v gives %v, %v", i, format.name, mat, actual, err)
+			}
+		}
+		if err := format.write(io.Discard, [][]int{{1, 2}, {3}}); !errors.Is(err, ErrRagged) {
+			t.Errorf("%s: writing a ragged matrix gives %v; want ErrRagged", format.name, err)
+		}
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	mat := [][]int{{1, 0, -3}, {0, 5, 0}}
+	tests := []struct {
+		name     string
+		write    func(io.Writer, [][]int) error
+		expected string
+	}{
+		{"text", WriteText, "1 0 -3\n0 5 0\n"},
+		{"CSV", WriteCSV, "1,0,-3\n0,5,0\n"},
+		{"MatrixMarket", WriteMatrixMarket,
+			"%%MatrixMarket matrix coordinate integer general\n2 3 3\n1 1 1\n1 3 -3\n2 2 5\n"},
+		{"JSON", WriteJSON, "[\n  [1,0,-3],\n  [0,5,0]\n]\n"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		test.write(&buf, mat)
+		if buf.String() != test.expected {
+			t.Errorf("%s: writing %v gives\n%s\nwant\n%s", test.name, mat, buf.String(), test.expected)
+		}
+	}
+	var buf bytes.Buffer
+	WriteJSON(&buf, [][]int{})
+	if buf.String() != "[]\n" {
+		t.Errorf("JSON: writing an empty matrix gives %q; want %q", buf.String(), "[]\n")
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		read     func(io.Reader) ([][]int, error)
+		input    string
+		expected [][]int
+		err      error
+	}{
+		{"text", ReadText, "", [][]int{}, nil},
+		{"text", ReadText, "\n 1\t2  3 \n\n-4 5 6\n", [][]int{{1, 2, 3}, {-4, 5, 6}}, nil},
+		{"text", ReadText, "1 2\n3\n", nil, ErrRagged},
+		{"text", ReadText, "1 x\n", nil, ErrSyntax},
+		{"CSV", ReadCSV, "", [][]int{}, nil},
+		{"CSV", ReadCSV, "1, 2\n3,-4\n", [][]int{{1, 2}, {3, -4}}, nil},
+		{"CSV", ReadCSV, "1,2\n3\n", nil, ErrRagged},
+		{"CSV", ReadCSV, "1,2.5\n", nil, ErrSyntax},
+		{"JSON", ReadJSON, "[]", [][]int{}, nil},
+		{"JSON", ReadJSON, "[[1, 2], [3, 4]]", [][]int{{1, 2}, {3, 4}}, nil},
+		{"JSON", ReadJSON, "[[1, 2], [3]]", nil, ErrRagged},
+		{"JSON", ReadJSON, "[[1, 2], [3, 4]", nil, io.ErrUnexpectedEOF},
+	}
+	for i, test := range tests {
+		actual, err := test.read(strings.NewReader(test.input))
+		if !reflect.DeepEqual(actual, test.expected) || !errors.Is(err, test.err) {
+			t.Errorf("#%d: %s: reading %q gives %v, %v; want %v, %v",
+				i, test.name, test.input, actual, err, test.expected, test.err)
+		}
+	}
+}
+
+// Lines longer than the 64 KiB of a default bufio.Scanner are read whole.
+func TestReadLongLines(t *testing.T) {
+	row := make([]int, 20000)
+	for j := range row {
+		row[j] = 100000 + j
+	}
+	mat := [][]int{row, row}
+	var buf bytes.Buffer
+	if err := WriteText(&buf, mat); err != nil {
+		t.Fatalf("WriteText gives %v", err)
+	}
+	if buf.Len() < 2*64<<10 {
+		t.Fatalf("The rows take only %d bytes", buf.Len())
+	}
+	if actual, err := ReadText(&buf); err != nil || !reflect.DeepEqual(actual, mat) {
+		t.Errorf("ReadText of two rows of %d entries gives %v", len(row), err)
+	}
+
+	input := "%%MatrixMarket matrix coordinate integer general\n%" + strings.Repeat("x", 100<<10) +
+		"\n1 1 1\n1 1 7\n"
+	if actual, err := ReadMatrixMarket(strings.NewReader(input)); err != nil || !reflect.DeepEqual(actual, [][]int{{7}}) {
+		t.Errorf("ReadMatrixMarket with a long comment gives %v, %v; want [[7]]", actual, err)
+	}
+}
+
+func TestReadMatrixMarket(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected [][]int
+		err      error
+	}{
+		{
+			"%%MatrixMarket matrix coordinate integer general\n% a comment\n\n2 3 2\n1 3 7\n2 1 -1\n",
+			[][]int{{0, 0, 7}, {-1, 0, 0}}, nil,
+		},
+		{
+			"%%MatrixMarket matrix coordinate pattern symmetric\n3 3 2\n2 1\n3 3\n",
+			[][]int{{0, 1, 0}, {1, 0, 0}, {0, 0, 1}}, nil,
+		},
+		{
+			"%%MatrixMarket matrix coordinate integer skew-symmetric\n2 2 1\n2 1 4\n",
+			[][]int{{0, -4}, {4, 0}}, nil,
+		},
+		{"%%MatrixMarket matrix coordinate integer general\n0 0 0\n", [][]int{}, nil},
+		{"", nil, ErrSyntax},
+		{"%%MatrixMarket matrix array integer general\n1 1\n5\n", nil, ErrSyntax},
+		{"%%MatrixMarket matrix coordinate real general\n1 1 1\n1 1 0.5\n", nil, ErrSyntax},
+		{"%%MatrixMarket matrix coordinate integer general\n2 2 2\n1 1 1\n", nil, io.ErrUnexpectedEOF},
+		{"%%MatrixMarket matrix coordinate integer general\n2 2 1\n3 1 1\n", nil, ErrSyntax},
+		{"%%MatrixMarket matrix coordinate integer general\n2 2 2\n1 1 1\n1 1 2\n", nil, ErrSyntax},
+		{"%%MatrixMarket matrix coordinate integer symmetric\n2 2 1\n1 2 1\n", nil, ErrSyntax},
+		{"%%MatrixMarket matrix coordinate integer symmetric\n2 3 0\n", nil, ErrSyntax},
+		{"%%MatrixMarket matrix coordinate integer general\n2 2\n", nil, ErrSyntax},
+		{"%%MatrixMarket matrix coordinate integer general\n1000000 1000000 0\n", nil, ErrTooLarge},
+		{"%%MatrixMarket matrix coordinate integer general\n100000000 0 0\n", nil, ErrTooLarge},
+		{"%%MatrixMarket matrix coordinate integer general\n4294967296 4294967296 0\n", nil, ErrTooLarge},
+	}
+	for i, test := range tests {
+		actual, err := ReadMatrixMarket(strings.NewReader(test.input))
+		if !reflect.DeepEqual(actual, test.expected) || !errors.Is(err, test.err) {
+			t.Errorf("#%d: ReadMatrixMarket(%q)=%v, %v; want %v, %v",
+				i, test.input, actual, err, test.expected, test.err)
+		}
+	}
+}
+
+func TestPretty(t *testing.T) {
+	tests := []struct {
+		mat                    [][]int
+		rowHeaders, colHeaders []string
+		expected               string
+	}{
+		{[][]int{}, nil, nil, ""},
+		{[][]int{{1, -20}, {300, 4}}, nil, nil, "  1 -20\n300   4\n"},
+		{
+			[][]int{{1, 2}, {3, 40}},
+			[]string{"first", "b"}, []string{"x", "y"},
+			"      x  y\nfirst 1  2\nb     3 40\n",
+		},
+		{[][]int{{7, 8}}, nil, []string{"long"}, "long  \n   7 8\n"},
+		{[][]int{{1}, {2}}, []string{"a"}, nil, "a 1\n  2\n"},
+	}
+	for i, test := range tests {
+		if actual := Pretty(test.mat, test.rowHeaders, test.colHeaders); actual != test.expected {
+			t.Errorf("#%d: Pretty(%v, %q, %q)=\n%s\nwant\n%s", i,
+				test.mat, test.rowHeaders, test.colHeaders, actual, test.expected)
+		}
+	}
+}
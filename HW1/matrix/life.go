@@ -0,0 +1,394 @@
+package matrix
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ErrRule is returned by ParseRule for a string that is not a rule.
+var ErrRule = errors.New("invalid rule")
+
+// Rule is a rule of a two-state cellular automaton: a dead cell with n live
+// neighbors becomes alive if Birth[n], and a live cell stays alive if
+// Survive[n].
+type Rule struct {
+	Birth, Survive [9]bool
+}
+
+// Life is the rule B3/S23 of Conway's Game of Life.
+var Life = Rule{
+	Birth:   [9]bool{3: true},
+	Survive: [9]bool{2: true, 3: true},
+}
+
+// ParseRule parses a rule in B/S notation, such as "B3/S23" for Life or
+// "B36/S23" for HighLife, in either order and either case. It also accepts the
+// older S/B notation without letters, such as "23/3".
+func ParseRule(s string) (Rule, error) {
+	var rule Rule
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return Rule{}, fmt.Errorf("%q: %w", s, ErrRule)
+	}
+	digits := func(part string, counts *[9]bool) error {
+		for _, c := range part {
+			if c < '0' || c > '8' {
+				return fmt.Errorf("%q: %w", s, ErrRule)
+			}
+			counts[c-'0'] = true
+		}
+		return nil
+	}
+	first, second := strings.ToUpper(parts[0]), strings.ToUpper(parts[1])
+	switch {
+	case strings.HasPrefix(first, "B") && strings.HasPrefix(second, "S"):
+		first, second = second, first
+		fallthrough
+	case strings.HasPrefix(first, "S") && strings.HasPrefix(second, "B"):
+		if err := digits(first[1:], &rule.Survive); err != nil {
+			return Rule{}, err
+		}
+		if err := digits(second[1:], &rule.Birth); err != nil {
+			return Rule{}, err
+		}
+	default:
+		if err := digits(first, &rule.Survive); err != nil {
+			return Rule{}, err
+		}
+		if err := digits(second, &rule.Birth); err != nil {
+			return Rule{}, err
+		}
+	}
+	return rule, nil
+}
+
+// String formats r in B/S notation, such as "B3/S23".
+func (r Rule) String() string {
+	var b strings.Builder
+	b.WriteByte('B')
+	for n, born := range r.Birth {
+		if born {
+			b.WriteByte(byte('0' + n))
+		}
+	}
+	b.WriteString("/S")
+	for n, survives := range r.Survive {
+		if survives {
+			b.WriteByte(byte('0' + n))
+		}
+	}
+	return b.String()
+}
+
+// Edges is what lies beyond the edges of the grid of an Automaton.
+type Edges int
+
+const (
+	// Bounded grids have dead cells all around them.
+	Bounded Edges = iota
+	// Toroidal grids wrap around, so that the cells of the left edge are
+	// the neighbors of those of the right edge, and the top of the bottom.
+	Toroidal
+)
+
+// Neighborhood is the set of cells that count as the neighbors of a cell.
+type Neighborhood int
+
+const (
+	// Moore neighborhoods are the eight cells around a cell.
+	Moore Neighborhood = iota
+	// VonNeumann neighborhoods are the four cells up, down, left and right
+	// of a cell, the neighbors of AreNeighbors.
+	VonNeumann
+)
+
+// Automaton is a two-state cellular automaton on a rectangular grid whose
+// cells are 1 if alive and 0 if dead. It keeps a second grid to compute the
+// next generation into, and swaps the two after every step.
+type Automaton struct {
+	Rule         Rule
+	Edges        Edges
+	Neighborhood Neighborhood
+	// Workers is the number of goroutines that Step splits the rows among,
+	// in bands of neighboring rows; up to 1 means Step works alone.
+	Workers int
+
+	cells, next [][]int
+	rows, cols  int
+}
+
+// NewAutomaton returns an Automaton with rule, on a grid that starts as a copy
+// of grid, where any cell that is not 0 is alive. It has bounded edges and
+// Moore neighborhoods until they are set otherwise. It returns ErrRagged if
+// grid is ragged.
+func NewAutomaton(grid [][]int, rule Rule) (*Automaton, error) {
+	rows, cols, err := Shape(grid)
+	if err != nil {
+		return nil, err
+	}
+	a := &Automaton{Rule: rule, cells: newMatrix(rows, cols), next: newMatrix(rows, cols), rows: rows, cols: cols}
+	for i := range grid {
+		for j, cell := range grid[i] {
+			if cell != 0 {
+				a.cells[i][j] = 1
+			}
+		}
+	}
+	return a, nil
+}
+
+// Grid returns a copy of the current generation.
+func (a *Automaton) Grid() [][]int {
+	grid := newMatrix(a.rows, a.cols)
+	for i := range grid {
+		copy(grid[i], a.cells[i])
+	}
+	return grid
+}
+
+// Population returns the number of live cells.
+func (a *Automaton) Population() int {
+	population := 0
+	for _, row := range a.cells {
+		for _, cell := range row {
+			population += cell
+		}
+	}
+	return population
+}
+
+// neighbors returns the number of live neighbors of the cell (i, j).
+func (a *Automaton) neighbors(i, j int) int {
+	count := 0
+	for di := -1; di <= 1; di++ {
+		for dj := -1; dj <= 1; dj++ {
+			if di == 0 && dj == 0 || a.Neighborhood == VonNeumann && di != 0 && dj != 0 {
+				continue
+			}
+			ni, nj := i+di, j+dj
+			if a.Edges == Toroidal {
+				ni, nj = (ni+a.rows)%a.rows, (nj+a.cols)%a.cols
+			} else if ni < 0 || ni >= a.rows || nj < 0 || nj >= a.cols {
+				continue
+			}
+			count += a.cells[ni][nj]
+		}
+	}
+	return count
+}
+
+// stepRows computes the next generation of the rows from up to but not
+// including to.
+func (a *Automaton) stepRows(from, to int) {
+	for i := from; i < to; i++ {
+		for j := range a.next[i] {
+			n := a.neighbors(i, j)
+			if a.cells[i][j] == 1 && a.Rule.Survive[n] || a.cells[i][j] == 0 && a.Rule.Birth[n] {
+				a.next[i][j] = 1
+			} else {
+				a.next[i][j] = 0
+			}
+		}
+	}
+}
+
+// Step advances the automaton by one generation.
+func (a *Automaton) Step() {
+	workers := min(a.Workers, a.rows)
+	if workers <= 1 {
+		a.stepRows(0, a.rows)
+	} else {
+		// every worker reads the current grid and writes only its own band
+		// of the next one
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(from, to int) {
+				defer wg.Done()
+				a.stepRows(from, to)
+			}(w*a.rows/workers, (w+1)*a.rows/workers)
+		}
+		wg.Wait()
+	}
+	a.cells, a.next = a.next, a.cells
+}
+
+// StepN advances the automaton by n generations.
+func (a *Automaton) StepN(n int) {
+	for ; n > 0; n-- {
+		a.Step()
+	}
+}
+
+// ReadRLE reads a pattern in the run length encoded format of Life programs:
+// comment lines starting with #, a header line such as
+//
+//	x = 3, y = 3, rule = B3/S23
+//
+// and runs of dead cells (b) and live cells (o), each with an optional count,
+// with rows ending in $ and the pattern in !. It returns the pattern as a y×x
+// grid and its rule, which is Life if the header gives none, and ErrTooLarge
+// if the grid is above MaxEntries.
+func ReadRLE(r io.Reader) ([][]int, Rule, error) {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	syntax := func(format string, args ...any) error {
+		return fmt.Errorf("line %d: %s: %w", line, fmt.Sprintf(format, args...), ErrSyntax)
+	}
+
+	var header string
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text != "" && !strings.HasPrefix(text, "#") {
+			header = text
+			break
+		}
+	}
+	if header == "" {
+		if err := scanner.Err(); err != nil {
+			return nil, Rule{}, err
+		}
+		return nil, Rule{}, fmt.Errorf("no header: %w", ErrSyntax)
+	}
+	cols, rows, rule := -1, -1, Life
+	for _, item := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, Rule{}, syntax("%q is not key = value", item)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		var err error
+		switch key {
+		case "x":
+			cols, err = strconv.Atoi(value)
+		case "y":
+			rows, err = strconv.Atoi(value)
+		case "rule":
+			rule, err = ParseRule(value)
+		default:
+			return nil, Rule{}, syntax("unknown key %q", key)
+		}
+		if err != nil {
+			return nil, Rule{}, syntax("bad %s %q", key, value)
+		}
+	}
+	if cols < 0 || rows < 0 {
+		return nil, Rule{}, syntax("the header gives no size x and y of at least 0")
+	}
+
+	if err := checkSize(rows, cols); err != nil {
+		return nil, Rule{}, err
+	}
+
+	grid := newMatrix(rows, cols)
+	i, j, count := 0, 0, 0
+	for scanner.Scan() {
+		line++
+		for _, c := range scanner.Text() {
+			switch {
+			case unicode.IsSpace(c):
+				continue
+			case c >= '0' && c <= '9':
+				// no run goes past both the row and the grid, which keeps
+				// the count far from overflowing
+				count = count*10 + int(c-'0')
+				if count > max(cols-j, rows-i) {
+					return nil, Rule{}, syntax("a run outside %d×%d", rows, cols)
+				}
+				continue
+			}
+			run := max(count, 1)
+			count = 0
+			switch c {
+			case 'b', 'o':
+				if j+run > cols || i >= rows {
+					return nil, Rule{}, syntax("a run outside %d×%d", rows, cols)
+				}
+				if c == 'o' {
+					for k := j; k < j+run; k++ {
+						grid[i][k] = 1
+					}
+				}
+				j += run
+			case '$':
+				i, j = min(i+run, rows), 0
+			case '!':
+				return grid, rule, nil
+			default:
+				return nil, Rule{}, syntax("unknown cell %q", c)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, Rule{}, err
+	}
+	return nil, Rule{}, fmt.Errorf("no ! at the end: %w", io.ErrUnexpectedEOF)
+}
+
+// WriteRLE writes grid, where any cell that is not 0 is alive, and rule in
+// the format ReadRLE reads, leaving out dead cells at the ends of rows and
+// keeping lines within 70 characters. It returns ErrRagged if grid is ragged.
+func WriteRLE(w io.Writer, grid [][]int, rule Rule) error {
+	rows, cols, err := Shape(grid)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "x = %d, y = %d, rule = %s\n", cols, rows, rule)
+
+	var runs []string
+	// pending row ends, written only once a live cell follows them
+	rowEnds := 0
+	add := func(count int, tag byte) {
+		if count > 1 {
+			runs = append(runs, strconv.Itoa(count)+string(tag))
+		} else {
+			runs = append(runs, string(tag))
+		}
+	}
+	for i := range grid {
+		if i > 0 {
+			rowEnds++
+		}
+		for j := 0; j < cols; {
+			k := j
+			for k < cols && (grid[i][k] != 0) == (grid[i][j] != 0) {
+				k++
+			}
+			if grid[i][j] != 0 {
+				if rowEnds > 0 {
+					add(rowEnds, '$')
+					rowEnds = 0
+				}
+				add(k-j, 'o')
+			} else if k < cols {
+				if rowEnds > 0 {
+					add(rowEnds, '$')
+					rowEnds = 0
+				}
+				add(k-j, 'b')
+			}
+			j = k
+		}
+	}
+	runs = append(runs, "!")
+
+	width := 0
+	for _, run := range runs {
+		if width+len(run) > 70 {
+			bw.WriteByte('\n')
+			width = 0
+		}
+		bw.WriteString(run)
+		width += len(run)
+	}
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
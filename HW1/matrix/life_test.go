@@ -0,0 +1,232 @@
+package matrix
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	highLife := Rule{Birth: [9]bool{3: true, 6: true}, Survive: [9]bool{2: true, 3: true}}
+	tests := []struct {
+		s        string
+		expected Rule
+		err      error
+	}{
+		{"B3/S23", Life, nil},
+		{"b3/s23", Life, nil},
+		{"S23/B3", Life, nil},
+		{"23/3", Life, nil},
+		{"B36/S23", highLife, nil},
+		{"B/S", Rule{}, nil},
+		{"B3", Rule{}, ErrRule},
+		{"B39/S23", Rule{}, ErrRule},
+		{"B3/X23", Rule{}, ErrRule},
+	}
+	for i, test := range tests {
+		actual, err := ParseRule(test.s)
+		if actual != test.expected || !errors.Is(err, test.err) {
+			t.Errorf("#%d: ParseRule(%q)=%v, %v; want %v, %v", i, test.s, actual, err, test.expected, test.err)
+		}
+	}
+	if s := highLife.String(); s != "B36/S23" {
+		t.Errorf("String()=%q; want %q", s, "B36/S23")
+	}
+}
+
+func TestAutomaton(t *testing.T) {
+	blinker := [][]int{{0, 0, 0}, {1, 1, 1}, {0, 0, 0}}
+	a, err := NewAutomaton(blinker, Life)
+	if err != nil {
+		t.Fatalf("NewAutomaton gives %v", err)
+	}
+	a.Step()
+	if grid := a.Grid(); !reflect.DeepEqual(grid, Transpose(blinker)) {
+		t.Errorf("A blinker after a step is %v; want %v", grid, Transpose(blinker))
+	}
+	a.Step()
+	if grid := a.Grid(); !reflect.DeepEqual(grid, blinker) {
+		t.Errorf("A blinker after two steps is %v; want %v", grid, blinker)
+	}
+
+	block := [][]int{{0, 0, 0, 0}, {0, 7, 1, 0}, {0, 1, 1, 0}, {0, 0, 0, 0}}
+	a, _ = NewAutomaton(block, Life)
+	a.StepN(5)
+	if a.Population() != 4 || a.Grid()[1][1] != 1 {
+		t.Errorf("A block after five steps is %v", a.Grid())
+	}
+
+	// a blinker on the edge of a bounded grid dies down to a pair, which dies
+	a, _ = NewAutomaton([][]int{{1, 1, 1}, {0, 0, 0}, {0, 0, 0}}, Life)
+	a.StepN(2)
+	if a.Population() != 0 {
+		t.Errorf("A bounded edge blinker leaves %v", a.Grid())
+	}
+	// on a torus the same row is a blinker wrapping around
+	a, _ = NewAutomaton([][]int{{1, 1, 1, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}}, Life)
+	a.Edges = Toroidal
+	a.Step()
+	if want := [][]int{{0, 1, 0, 0}, {0, 1, 0, 0}, {0, 0, 0, 0}, {0, 1, 0, 0}}; !reflect.DeepEqual(a.Grid(), want) {
+		t.Errorf("A toroidal edge blinker after a step is %v; want %v", a.Grid(), want)
+	}
+
+	if _, err := NewAutomaton([][]int{{1, 1}, {1}}, Life); !errors.Is(err, ErrRagged) {
+		t.Errorf("NewAutomaton of a ragged grid gives %v; want ErrRagged", err)
+	}
+}
+
+// A glider moves one cell diagonally every four steps, so on a torus it is
+// back where it started after four steps per cell of the side.
+func TestGliderOnTorus(t *testing.T) {
+	grid := newMatrix(8, 8)
+	grid[0][1], grid[1][2], grid[2][0], grid[2][1], grid[2][2] = 1, 1, 1, 1, 1
+	a, _ := NewAutomaton(grid, Life)
+	a.Edges = Toroidal
+	a.StepN(4)
+	if actual := a.Grid(); actual[1][2] != 1 || actual[3][3] != 1 || a.Population() != 5 {
+		t.Errorf("A glider after four steps is %v", actual)
+	}
+	a.StepN(28)
+	if actual := a.Grid(); !reflect.DeepEqual(actual, grid) {
+		t.Errorf("A glider after 32 steps is %v; want %v", actual, grid)
+	}
+}
+
+func TestVonNeumann(t *testing.T) {
+	// B1/S: every cell with exactly one neighbor is born, and no cell
+	// survives; a single cell becomes a diamond without its center
+	rule, _ := ParseRule("B1/S")
+	grid := newMatrix(5, 5)
+	grid[2][2] = 1
+	a, _ := NewAutomaton(grid, rule)
+	a.Neighborhood = VonNeumann
+	a.Step()
+	for i, row := range a.Grid() {
+		for j, cell := range row {
+			want := 0
+			if AreNeighbors([][]int{{0, 1, 2, 3, 4}, {5, 6, 7, 8, 9}, {10, 11, 12, 13, 14},
+				{15, 16, 17, 18, 19}, {20, 21, 22, 23, 24}}, 12, 5*i+j) {
+				want = 1
+			}
+			if cell != want {
+				t.Errorf("Cell (%d, %d) is %d; want %d", i, j, cell, want)
+			}
+		}
+	}
+}
+
+// Parallel steps in bands give the same generations as steps alone.
+func TestAutomatonWorkers(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	grid := newMatrix(37, 23)
+	for i := range grid {
+		for j := range grid[i] {
+			grid[i][j] = rng.Intn(2)
+		}
+	}
+	for _, edges := range []Edges{Bounded, Toroidal} {
+		alone, _ := NewAutomaton(grid, Life)
+		together, _ := NewAutomaton(grid, Life)
+		alone.Edges, together.Edges = edges, edges
+		together.Workers = 4
+		for step := 0; step < 20; step++ {
+			alone.Step()
+			together.Step()
+			if !reflect.DeepEqual(alone.Grid(), together.Grid()) {
+				t.Fatalf("Edges %d, step %d: parallel steps differ", edges, step)
+			}
+		}
+	}
+}
+
+func TestRLE(t *testing.T) {
+	glider := "#N Glider\n#C a comment\nx = 3, y = 3, rule = B3/S23\nbo$2bo$3o!\n"
+	grid, rule, err := ReadRLE(strings.NewReader(glider))
+	want := [][]int{{0, 1, 0}, {0, 0, 1}, {1, 1, 1}}
+	if !reflect.DeepEqual(grid, want) || rule != Life || err != nil {
+		t.Errorf("ReadRLE(%q)=%v, %v, %v; want %v, B3/S23", glider, grid, rule, err, want)
+	}
+	var buf bytes.Buffer
+	WriteRLE(&buf, want, Life)
+	if buf.String() != "x = 3, y = 3, rule = B3/S23\nbo$2bo$3o!\n" {
+		t.Errorf("WriteRLE of a glider gives %q", buf.String())
+	}
+
+	tests := []struct {
+		input    string
+		expected [][]int
+		err      error
+	}{
+		{"x = 4, y = 3\n2o$\n3$", nil, io.ErrUnexpectedEOF},
+		{"x = 4, y = 4\n2o2$\n4o!", [][]int{{1, 1, 0, 0}, {0, 0, 0, 0}, {1, 1, 1, 1}, {0, 0, 0, 0}}, nil},
+		{"x = 2, y = 1\n3o!", nil, ErrSyntax},
+		{"x = 2, y = 1\nox!", nil, ErrSyntax},
+		{"x = 2\no!", nil, ErrSyntax},
+		{"x = 2, y = 1, rule = B9/S\no!", nil, ErrSyntax},
+		{"", nil, ErrSyntax},
+		{"x = 3, y = 1\no9223372036854775807bo!", nil, ErrSyntax},
+		{"x = 3, y = 1\n99999999999999999999999o!", nil, ErrSyntax},
+		{"x = 3, y = 2\no9223372036854775807$o!", nil, ErrSyntax},
+		{"x = 3, y = 2\no4o!", nil, ErrSyntax},
+		{"x = 2, y = 2\no2$!", [][]int{{1, 0}, {0, 0}}, nil},
+		{"x = 2, y = 2\no5$!", nil, ErrSyntax},
+		{"x = 4294967296, y = 4294967296\n!", nil, ErrTooLarge},
+		{"x = 0, y = 100000000\n!", nil, ErrTooLarge},
+	}
+	for i, test := range tests {
+		grid, _, err := ReadRLE(strings.NewReader(test.input))
+		if !reflect.DeepEqual(grid, test.expected) || !errors.Is(err, test.err) {
+			t.Errorf("#%d: ReadRLE(%q)=%v, %v; want %v, %v", i, test.input, grid, err, test.expected, test.err)
+		}
+	}
+
+	// random grids, with long lines and empty rows, survive a round trip
+	rng := rand.New(rand.NewSource(1))
+	highLife, _ := ParseRule("B36/S23")
+	for round := 0; round < 10; round++ {
+		grid := newMatrix(1+rng.Intn(10), 1+rng.Intn(100))
+		for i := range grid {
+			if rng.Intn(3) > 0 {
+				for j := range grid[i] {
+					grid[i][j] = rng.Intn(2)
+				}
+			}
+		}
+		var buf bytes.Buffer
+		if err := WriteRLE(&buf, grid, highLife); err != nil {
+			t.Fatalf("WriteRLE gives %v", err)
+		}
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if len(line) > 70 {
+				t.Errorf("WriteRLE writes a line of %d characters", len(line))
+			}
+		}
+		actual, rule, err := ReadRLE(&buf)
+		if !reflect.DeepEqual(actual, grid) || rule != highLife || err != nil {
+			t.Errorf("Round trip of %v gives %v, %v, %v", grid, actual, rule, err)
+		}
+	}
+}
+
+func benchmarkLife(b *testing.B, workers int) {
+	rng := rand.New(rand.NewSource(1))
+	grid := newMatrix(256, 256)
+	for i := range grid {
+		for j := range grid[i] {
+			grid[i][j] = rng.Intn(2)
+		}
+	}
+	a, _ := NewAutomaton(grid, Life)
+	a.Edges, a.Workers = Toroidal, workers
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		a.Step()
+	}
+}
+
+func BenchmarkLife(b *testing.B)         { benchmarkLife(b, 1) }
+func BenchmarkLifeParallel(b *testing.B) { benchmarkLife(b, 4) }
@@ -0,0 +1,144 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrShape is returned when the shapes of matrices do not fit the operation,
+// such as a product of a 2×3 and a 2×3 matrix or the power of a matrix that
+// is not square.
+var ErrShape = errors.New("mismatched shapes")
+
+// Identity returns the n×n identity matrix.
+func Identity(n int) [][]int {
+	id := make([][]int, n)
+	for i := range id {
+		id[i] = make([]int, n)
+		id[i][i] = 1
+	}
+	return id
+}
+
+// Multiply returns the product of a and b. Entries that do not fit in an int
+// wrap around; use MultiplyBig if they may not.
+func Multiply(a, b [][]int) ([][]int, error) {
+	n, m, err := Shape(a)
+	if err != nil {
+		return nil, err
+	}
+	m2, p, err := Shape(b)
+	if err != nil {
+		return nil, err
+	}
+	// an empty b is 0×0, which only an n×0 a fits
+	if m != m2 {
+		return nil, fmt.Errorf("%d×%d times %d×%d: %w", n, m, m2, p, ErrShape)
+	}
+	product := make([][]int, n)
+	for i := range product {
+		product[i] = make([]int, p)
+		for k, aik := range a[i] {
+			if aik == 0 {
+				continue
+			}
+			for j, bkj := range b[k] {
+				product[i][j] += aik * bkj
+			}
+		}
+	}
+	return product, nil
+}
+
+// Power returns mat to the power k, computed by repeated squaring with
+// O(log k) products, that is, in O(n³ log k) time for an n×n matrix. The
+// power 0 is the identity. Entries that do not fit in an int wrap around; use
+// PowerBig if they may not.
+func Power(mat [][]int, k uint) ([][]int, error) {
+	n, m, err := Shape(mat)
+	if err != nil {
+		return nil, err
+	}
+	if n != m {
+		return nil, fmt.Errorf("power of %d×%d: %w", n, m, ErrShape)
+	}
+	result, square := Identity(n), mat
+	for ; k > 0; k >>= 1 {
+		if k&1 == 1 {
+			result, _ = Multiply(result, square)
+		}
+		if k > 1 {
+			square, _ = Multiply(square, square)
+		}
+	}
+	return result, nil
+}
+
+// ToBig returns mat with its entries as big.Ints.
+func ToBig(mat [][]int) [][]*big.Int {
+	result := make([][]*big.Int, len(mat))
+	for i, row := range mat {
+		result[i] = make([]*big.Int, len(row))
+		for j, n := range row {
+			result[i][j] = big.NewInt(int64(n))
+		}
+	}
+	return result
+}
+
+// MultiplyBig is like Multiply but computes with big.Ints.
+func MultiplyBig(a, b [][]*big.Int) ([][]*big.Int, error) {
+	n, m, err := shapeOf(a)
+	if err != nil {
+		return nil, err
+	}
+	m2, p, err := shapeOf(b)
+	if err != nil {
+		return nil, err
+	}
+	if m != m2 {
+		return nil, fmt.Errorf("%d×%d times %d×%d: %w", n, m, m2, p, ErrShape)
+	}
+	product := make([][]*big.Int, n)
+	term := new(big.Int)
+	for i := range product {
+		product[i] = make([]*big.Int, p)
+		for j := range product[i] {
+			product[i][j] = new(big.Int)
+		}
+		for k, aik := range a[i] {
+			if aik.Sign() == 0 {
+				continue
+			}
+			for j, bkj := range b[k] {
+				product[i][j].Add(product[i][j], term.Mul(aik, bkj))
+			}
+		}
+	}
+	return product, nil
+}
+
+// PowerBig is like Power but computes with big.Ints, so that the entries of
+// the power, such as the numbers of walks of length k in a graph, never
+// overflow. Package lgraph, which cannot import this package, keeps a copy of
+// PowerBig and MultiplyBig for lgraph.CountWalks; keep the two in step.
+func PowerBig(mat [][]int, k uint) ([][]*big.Int, error) {
+	n, m, err := Shape(mat)
+	if err != nil {
+		return nil, err
+	}
+	if n != m {
+		return nil, fmt.Errorf("power of %d×%d: %w", n, m, ErrShape)
+	}
+	result, square := ToBig(Identity(n)), ToBig(mat)
+	for ; k > 0; k >>= 1 {
+		if k&1 == 1 {
+			result, _ = MultiplyBig(result, square)
+		}
+		if k > 1 {
+			square, _ = MultiplyBig(square, square)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package matrix
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestMultiply(t *testing.T) {
+	tests := []struct {
+		a, b, expected [][]int
+		err            error
+	}{
+		{[][]int{}, [][]int{}, [][]int{}, nil},
+		{[][]int{{1, 2}, {3, 4}}, [][]int{{5, 6}, {7, 8}}, [][]int{{19, 22}, {43, 50}}, nil},
+		{[][]int{{1, 2, 3}}, [][]int{{1}, {0}, {-1}}, [][]int{{-2}}, nil},
+		{[][]int{{1}, {2}}, [][]int{{3, 4}}, [][]int{{3, 4}, {6, 8}}, nil},
+		{[][]int{{1, 2}}, [][]int{{1, 2}}, nil, ErrShape},
+		{[][]int{{1, 2}, {3}}, [][]int{{1}, {2}}, nil, ErrRagged},
+		{[][]int{{1, 2}}, [][]int{{1}, {2, 3}}, nil, ErrRagged},
+		{[][]int{{1}}, [][]int{}, nil, ErrShape},
+		{[][]int{{1, 2}}, [][]int{}, nil, ErrShape},
+		{[][]int{{}, {}}, [][]int{}, [][]int{{}, {}}, nil},
+	}
+	for i, test := range tests {
+		actual, err := Multiply(test.a, test.b)
+		if !reflect.DeepEqual(actual, test.expected) || !errors.Is(err, test.err) {
+			t.Errorf("#%d: Multiply(%v, %v)=%v, %v; want %v, %v", i,
+				test.a, test.b, actual, err, test.expected, test.err)
+		}
+		actualBig, err := MultiplyBig(ToBig(test.a), ToBig(test.b))
+		if test.expected != nil && !reflect.DeepEqual(actualBig, ToBig(test.expected)) ||
+			!errors.Is(err, test.err) {
+			t.Errorf("#%d: MultiplyBig(%v, %v)=%v, %v; want %v, %v", i,
+				test.a, test.b, actualBig, err, test.expected, test.err)
+		}
+	}
+}
+
+func TestPower(t *testing.T) {
+	fibonacci := [][]int{{1, 1}, {1, 0}}
+	tests := []struct {
+		mat      [][]int
+		k        uint
+		expected [][]int
+		err      error
+	}{
+		{[][]int{}, 5, [][]int{}, nil},
+		{fibonacci, 0, [][]int{{1, 0}, {0, 1}}, nil},
+		{fibonacci, 1, fibonacci, nil},
+		{fibonacci, 10, [][]int{{89, 55}, {55, 34}}, nil},
+		{[][]int{{2}}, 62, [][]int{{1 << 62}}, nil},
+		// walks in a directed triangle come back every third step
+		{[][]int{{0, 1, 0}, {0, 0, 1}, {1, 0, 0}}, 7, [][]int{{0, 1, 0}, {0, 0, 1}, {1, 0, 0}}, nil},
+		{[][]int{{1, 2}}, 2, nil, ErrShape},
+		{[][]int{{1, 2}, {3}}, 2, nil, ErrRagged},
+	}
+	for i, test := range tests {
+		actual, err := Power(test.mat, test.k)
+		if !reflect.DeepEqual(actual, test.expected) || !errors.Is(err, test.err) {
+			t.Errorf("#%d: Power(%v, %d)=%v, %v; want %v, %v", i,
+				test.mat, test.k, actual, err, test.expected, test.err)
+		}
+		actualBig, err := PowerBig(test.mat, test.k)
+		if test.expected != nil && !reflect.DeepEqual(actualBig, ToBig(test.expected)) ||
+			!errors.Is(err, test.err) {
+			t.Errorf("#%d: PowerBig(%v, %d)=%v, %v; want %v, %v", i,
+				test.mat, test.k, actualBig, err, test.expected, test.err)
+		}
+	}
+}
+
+func TestPowerBigOverflow(t *testing.T) {
+	// the 200th Fibonacci number is far beyond an int
+	power, err := PowerBig([][]int{{1, 1}, {1, 0}}, 199)
+	want, _ := new(big.Int).SetString("280571172992510140037611932413038677189525", 10)
+	if err != nil || power[0][0].Cmp(want) != 0 {
+		t.Errorf("PowerBig(fibonacci, 199)[0][0]=%v, %v; want %v", power[0][0], err, want)
+	}
+}
@@ -0,0 +1,231 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// ErrRotation is returned by Rotate for an angle that is not a multiple of 90
+// degrees.
+var ErrRotation = errors.New("rotation by other than a multiple of 90 degrees")
+
+// blockSize is the size below which TransposeCacheOblivious stops splitting
+// and copies directly; a block of ints this size fits in the smallest caches.
+const blockSize = 16
+
+// Shape returns the numbers of rows and columns of mat. It returns ErrRagged
+// if the rows do not all have the same length. The empty matrix is 0×0.
+func Shape(mat [][]int) (int, int, error) {
+	return shapeOf(mat)
+}
+
+// shapeOf is Shape for matrices of any type.
+func shapeOf[T any](mat [][]T) (int, int, error) {
+	if err := checkRows(mat); err != nil {
+		return 0, 0, err
+	}
+	if len(mat) == 0 {
+		return 0, 0, nil
+	}
+	return len(mat), len(mat[0]), nil
+}
+
+// newMatrix returns a rows×cols matrix of zeros whose rows share one backing
+// array.
+func newMatrix(rows, cols int) [][]int {
+	mat := make([][]int, rows)
+	backing := make([]int, rows*cols)
+	for i := range mat {
+		mat[i] = backing[i*cols : (i+1)*cols : (i+1)*cols]
+	}
+	return mat
+}
+
+// TransposeChecked is like Transpose but returns ErrRagged instead of
+// panicking if the rows of mat do not all have the same length.
+func TransposeChecked(mat [][]int) ([][]int, error) {
+	if _, _, err := Shape(mat); err != nil {
+		return nil, err
+	}
+	return Transpose(mat), nil
+}
+
+// TransposeInPlace transposes the square matrix mat by swapping the entries
+// above the diagonal with those below it, without allocating. It returns
+// ErrShape if mat is not square.
+func TransposeInPlace(mat [][]int) error {
+	rows, cols, err := Shape(mat)
+	if err != nil {
+		return err
+	}
+	if rows != cols {
+		return fmt.Errorf("in-place transpose of %d×%d: %w", rows, cols, ErrShape)
+	}
+	for i := range mat {
+		for j := i + 1; j < cols; j++ {
+			mat[i][j], mat[j][i] = mat[j][i], mat[i][j]
+		}
+	}
+	return nil
+}
+
+// TransposeCacheOblivious returns the transpose of mat like TransposeChecked,
+// but splits the matrix in halves along its longer side until the blocks are
+// small, so that every block is read and written while it is in the cache,
+// whatever the cache sizes are. It is faster than Transpose for large
+// matrices.
+func TransposeCacheOblivious(mat [][]int) ([][]int, error) {
+	rows, cols, err := Shape(mat)
+	if err != nil {
+		return nil, err
+	}
+	if mat == nil {
+		return nil, nil
+	}
+	result := newMatrix(cols, rows)
+	var transpose func(r0, r1, c0, c1 int)
+	transpose = func(r0, r1, c0, c1 int) {
+		switch {
+		case r1-r0 <= blockSize && c1-c0 <= blockSize:
+			for i := r0; i < r1; i++ {
+				for j := c0; j < c1; j++ {
+					result[j][i] = mat[i][j]
+				}
+			}
+		case r1-r0 >= c1-c0:
+			mid := (r0 + r1) / 2
+			transpose(r0, mid, c0, c1)
+			transpose(mid, r1, c0, c1)
+		default:
+			mid := (c0 + c1) / 2
+			transpose(r0, r1, c0, mid)
+			transpose(r0, r1, mid, c1)
+		}
+	}
+	transpose(0, rows, 0, cols)
+	return result, nil
+}
+
+// Rotate returns mat rotated clockwise by degrees, which must be a multiple
+// of 90; negative angles rotate counterclockwise. It returns ErrRotation for
+// other angles and ErrRagged for a ragged mat.
+func Rotate(mat [][]int, degrees int) ([][]int, error) {
+	rows, cols, err := Shape(mat)
+	if err != nil {
+		return nil, err
+	}
+	if degrees%90 != 0 {
+		return nil, fmt.Errorf("%d degrees: %w", degrees, ErrRotation)
+	}
+	var result [][]int
+	switch (degrees/90%4 + 4) % 4 {
+	case 0:
+		result = newMatrix(rows, cols)
+		for i := range mat {
+			copy(result[i], mat[i])
+		}
+	case 1:
+		result = newMatrix(cols, rows)
+		for i := range mat {
+			for j, n := range mat[i] {
+				result[j][rows-1-i] = n
+			}
+		}
+	case 2:
+		result = newMatrix(rows, cols)
+		for i := range mat {
+			for j, n := range mat[i] {
+				result[rows-1-i][cols-1-j] = n
+			}
+		}
+	case 3:
+		result = newMatrix(cols, rows)
+		for i := range mat {
+			for j, n := range mat[i] {
+				result[cols-1-j][i] = n
+			}
+		}
+	}
+	return result, nil
+}
+
+// FlipHorizontal returns mat mirrored left to right, with every row reversed.
+func FlipHorizontal(mat [][]int) ([][]int, error) {
+	rows, cols, err := Shape(mat)
+	if err != nil {
+		return nil, err
+	}
+	result := newMatrix(rows, cols)
+	for i := range mat {
+		for j, n := range mat[i] {
+			result[i][cols-1-j] = n
+		}
+	}
+	return result, nil
+}
+
+// FlipVertical returns mat mirrored top to bottom, with the rows in reverse
+// order.
+func FlipVertical(mat [][]int) ([][]int, error) {
+	rows, cols, err := Shape(mat)
+	if err != nil {
+		return nil, err
+	}
+	result := newMatrix(rows, cols)
+	for i := range mat {
+		copy(result[rows-1-i], mat[i])
+	}
+	return result, nil
+}
+
+// Spiral returns the positions (row, column) of a rows×cols matrix in
+// clockwise spiral order, starting at the top left corner and going right.
+func Spiral(rows, cols int) iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		top, bottom, left, right := 0, rows-1, 0, cols-1
+		for top <= bottom && left <= right {
+			for j := left; j <= right; j++ {
+				if !yield(top, j) {
+					return
+				}
+			}
+			for i := top + 1; i <= bottom; i++ {
+				if !yield(i, right) {
+					return
+				}
+			}
+			if top < bottom {
+				for j := right - 1; j >= left; j-- {
+					if !yield(bottom, j) {
+						return
+					}
+				}
+			}
+			if left < right {
+				for i := bottom - 1; i > top; i-- {
+					if !yield(i, left) {
+						return
+					}
+				}
+			}
+			top, bottom, left, right = top+1, bottom-1, left+1, right-1
+		}
+	}
+}
+
+// Diagonals returns the positions (row, column) of a rows×cols matrix one
+// anti-diagonal after the other, starting at the top left corner. Within an
+// anti-diagonal, where row+column is the same, the positions go from the top
+// right to the bottom left.
+func Diagonals(rows, cols int) iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		for d := 0; d < rows+cols-1; d++ {
+			for i := max(0, d-cols+1); i <= min(d, rows-1); i++ {
+				if !yield(i, d-i) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package matrix
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+var ragged = [][]int{{1, 2}, {3}}
+
+func TestShape(t *testing.T) {
+	tests := []struct {
+		mat        [][]int
+		rows, cols int
+		err        error
+	}{
+		{nil, 0, 0, nil},
+		{[][]int{}, 0, 0, nil},
+		{[][]int{{}, {}}, 2, 0, nil},
+		{[][]int{{1, 2, 3}, {4, 5, 6}}, 2, 3, nil},
+		{ragged, 0, 0, ErrRagged},
+		{[][]int{{1}, {2, 3}}, 0, 0, ErrRagged},
+	}
+	for i, test := range tests {
+		rows, cols, err := Shape(test.mat)
+		if rows != test.rows || cols != test.cols || !errors.Is(err, test.err) {
+			t.Errorf("#%d: Shape(%v)=%d, %d, %v; want %d, %d, %v", i,
+				test.mat, rows, cols, err, test.rows, test.cols, test.err)
+		}
+	}
+}
+
+func TestTransposeChecked(t *testing.T) {
+	if _, err := TransposeChecked(ragged); !errors.Is(err, ErrRagged) {
+		t.Errorf("TransposeChecked(%v) gives %v; want ErrRagged", ragged, err)
+	}
+	if _, err := TransposeCacheOblivious(ragged); !errors.Is(err, ErrRagged) {
+		t.Errorf("TransposeCacheOblivious(%v) gives %v; want ErrRagged", ragged, err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range [][2]int{{0, 0}, {1, 1}, {3, 5}, {17, 2}, {40, 33}, {100, 7}} {
+		mat := newMatrix(size[0], size[1])
+		for i := range mat {
+			for j := range mat[i] {
+				mat[i][j] = rng.Intn(100)
+			}
+		}
+		want := Transpose(mat)
+		if actual, err := TransposeChecked(mat); err != nil || !reflect.DeepEqual(actual, want) {
+			t.Errorf("TransposeChecked of %d×%d=%v, %v; want %v", size[0], size[1], actual, err, want)
+		}
+		if actual, err := TransposeCacheOblivious(mat); err != nil || !reflect.DeepEqual(actual, want) {
+			t.Errorf("TransposeCacheOblivious of %d×%d=%v, %v; want %v", size[0], size[1], actual, err, want)
+		}
+		if size[0] == size[1] {
+			if err := TransposeInPlace(mat); err != nil || !reflect.DeepEqual(mat, want) {
+				t.Errorf("TransposeInPlace of %d×%d=%v, %v; want %v", size[0], size[1], mat, err, want)
+			}
+		}
+	}
+	if actual, err := TransposeCacheOblivious(nil); actual != nil || err != nil {
+		t.Errorf("TransposeCacheOblivious(nil)=%v, %v; want nil, nil", actual, err)
+	}
+	if err := TransposeInPlace([][]int{{1, 2}}); !errors.Is(err, ErrShape) {
+		t.Errorf("TransposeInPlace of 1×2 gives %v; want ErrShape", err)
+	}
+}
+
+func TestRotateAndFlip(t *testing.T) {
+	mat := [][]int{{1, 2, 3}, {4, 5, 6}}
+	tests := []struct {
+		degrees  int
+		expected [][]int
+		err      error
+	}{
+		{0, [][]int{{1, 2, 3}, {4, 5, 6}}, nil},
+		{90, [][]int{{4, 1}, {5, 2}, {6, 3}}, nil},
+		{180, [][]int{{6, 5, 4}, {3, 2, 1}}, nil},
+		{270, [][]int{{3, 6}, {2, 5}, {1, 4}}, nil},
+		{-90, [][]int{{3, 6}, {2, 5}, {1, 4}}, nil},
+		{450, [][]int{{4, 1}, {5, 2}, {6, 3}}, nil},
+		{45, nil, ErrRotation},
+	}
+	for i, test := range tests {
+		actual, err := Rotate(mat, test.degrees)
+		if !reflect.DeepEqual(actual, test.expected) || !errors.Is(err, test.err) {
+			t.Errorf("#%d: Rotate(%v, %d)=%v, %v; want %v, %v", i,
+				mat, test.degrees, actual, err, test.expected, test.err)
+		}
+	}
+	if _, err := Rotate(ragged, 90); !errors.Is(err, ErrRagged) {
+		t.Errorf("Rotate(%v, 90) gives %v; want ErrRagged", ragged, err)
+	}
+
+	if actual, _ := FlipHorizontal(mat); !reflect.DeepEqual(actual, [][]int{{3, 2, 1}, {6, 5, 4}}) {
+		t.Errorf("FlipHorizontal(%v)=%v", mat, actual)
+	}
+	if actual, _ := FlipVertical(mat); !reflect.DeepEqual(actual, [][]int{{4, 5, 6}, {1, 2, 3}}) {
+		t.Errorf("FlipVertical(%v)=%v", mat, actual)
+	}
+	if _, err := FlipVertical(ragged); !errors.Is(err, ErrRagged) {
+		t.Errorf("FlipVertical(%v) gives %v; want ErrRagged", ragged, err)
+	}
+	// a flip and a transpose make a rotation
+	flipped, _ := FlipVertical(mat)
+	rotated, _ := Rotate(mat, 90)
+	if actual := Transpose(flipped); !reflect.DeepEqual(actual, rotated) {
+		t.Errorf("Transpose(FlipVertical(%v))=%v; want %v", mat, actual, rotated)
+	}
+	if mat[0][0] != 1 {
+		t.Errorf("Rotating and flipping changed the matrix to %v", mat)
+	}
+}
+
+// positions collects the positions of an iterator, stopping after limit.
+func positions(seq func(func(int, int) bool), limit int) [][2]int {
+	result := [][2]int{}
+	for i, j := range seq {
+		if len(result) == limit {
+			break
+		}
+		result = append(result, [2]int{i, j})
+	}
+	return result
+}
+
+func TestSpiralAndDiagonals(t *testing.T) {
+	tests := []struct {
+		name       string
+		seq        func(int, int) func(func(int, int) bool)
+		rows, cols int
+		expected   [][2]int
+	}{
+		{"Spiral", spiral, 0, 3, [][2]int{}},
+		{"Spiral", spiral, 1, 3, [][2]int{{0, 0}, {0, 1}, {0, 2}}},
+		{"Spiral", spiral, 3, 1, [][2]int{{0, 0}, {1, 0}, {2, 0}}},
+		{"Spiral", spiral, 3, 3, [][2]int{
+			{0, 0}, {0, 1}, {0, 2}, {1, 2}, {2, 2}, {2, 1}, {2, 0}, {1, 0}, {1, 1}}},
+		{"Spiral", spiral, 3, 4, [][2]int{
+			{0, 0}, {0, 1}, {0, 2}, {0, 3}, {1, 3}, {2, 3}, {2, 2}, {2, 1}, {2, 0}, {1, 0}, {1, 1}, {1, 2}}},
+		{"Diagonals", diagonals, 0, 0, [][2]int{}},
+		{"Diagonals", diagonals, 2, 3, [][2]int{{0, 0}, {0, 1}, {1, 0}, {0, 2}, {1, 1}, {1, 2}}},
+		{"Diagonals", diagonals, 3, 1, [][2]int{{0, 0}, {1, 0}, {2, 0}}},
+	}
+	for i, test := range tests {
+		if actual := positions(test.seq(test.rows, test.cols), -1); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("#%d: %s(%d, %d)=%v; want %v", i, test.name, test.rows, test.cols, actual, test.expected)
+		}
+	}
+	if actual := positions(spiral(4, 4), 5); len(actual) != 5 {
+		t.Errorf("Stopping a spiral early gives %v", actual)
+	}
+	if actual := positions(diagonals(4, 4), 2); len(actual) != 2 {
+		t.Errorf("Stopping diagonals early gives %v", actual)
+	}
+}
+
+func spiral(rows, cols int) func(func(int, int) bool)    { return Spiral(rows, cols) }
+func diagonals(rows, cols int) func(func(int, int) bool) { return Diagonals(rows, cols) }
+
+func BenchmarkTranspose(b *testing.B) {
+	mat := newMatrix(1024, 1024)
+	for i := 0; i < b.N; i++ {
+		Transpose(mat)
+	}
+}
+
+func BenchmarkTransposeCacheOblivious(b *testing.B) {
+	mat := newMatrix(1024, 1024)
+	for i := 0; i < b.N; i++ {
+		TransposeCacheOblivious(mat)
+	}
+}